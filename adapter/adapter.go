@@ -1,11 +1,17 @@
 package adapter
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"code.google.com/p/go-uuid/uuid"
 	"github.com/CenturyLinkLabs/pmxadapter"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
@@ -13,7 +19,9 @@ import (
 )
 
 const (
-	metadataType = "Kubernetes"
+	metadataType         = "Kubernetes"
+	definitionAnnotation = "panamax.io/definition"
+	applicationLabel     = "panamax-application"
 )
 
 var (
@@ -21,6 +29,13 @@ var (
 	DefaultExecutor       Executor
 	illegalNameCharacters = regexp.MustCompile(`[\W_]+`)
 	PublicIPs             []string
+	pollInterval          = time.Second
+	newApplicationID      = uuid.New
+	// OrphanCollectionInterval is how often orphaned objects are collected,
+	// with zero meaning never.
+	OrphanCollectionInterval time.Duration
+	apiUsername              string
+	apiPassword              string
 )
 
 func init() {
@@ -30,6 +45,80 @@ func init() {
 		PublicIPs = []string{publicIP}
 	}
 
+	apiUsername = os.Getenv("ADAPTER_USERNAME")
+	apiPassword = os.Getenv("ADAPTER_PASSWORD")
+
+	if port := os.Getenv("KUBELET_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			log.Fatalf("There was a problem with your kubelet port: %v", err)
+		}
+		kubeletPort = p
+	}
+
+	for name, variable := range map[api.ResourceName]string{
+		api.ResourceCPU:    "DEFAULT_CPU_LIMIT",
+		api.ResourceMemory: "DEFAULT_MEMORY_LIMIT",
+	} {
+		if value := os.Getenv(variable); value != "" {
+			q, err := parseLimit(value)
+			if err != nil {
+				log.Fatalf("There was a problem with your %v: it %v", variable, err)
+			}
+			defaultLimits[name] = *q
+		}
+	}
+
+	if patterns := os.Getenv("SECRET_VARIABLES"); patterns != "" {
+		for _, p := range strings.Split(patterns, ",") {
+			p = strings.TrimSpace(p)
+			if _, err := path.Match(p, ""); err != nil {
+				log.Fatalf("There was a problem with your secret variable pattern '%v': %v", p, err)
+			}
+			secretPatterns = append(secretPatterns, p)
+		}
+	}
+
+	if policy := os.Getenv("IMAGE_PULL_POLICY"); policy != "" {
+		p, err := parsePullPolicy(policy)
+		if err != nil {
+			log.Fatalf("There was a problem with your image pull policy: it %v", err)
+		}
+		defaultPullPolicy = p
+	}
+
+	if selector := os.Getenv("DEFAULT_NODE_SELECTOR"); selector != "" {
+		s, err := parseNodeSelector(selector)
+		if err != nil {
+			log.Fatalf("There was a problem with your default node selector: it %v", err)
+		}
+		defaultNodeSelector = s
+	}
+
+	if file := os.Getenv("POLICY_FILE"); file != "" {
+		p, err := readPolicy(file)
+		if err != nil {
+			log.Fatalf("There was a problem with your policy file: %v", err)
+		}
+		policy = p
+	}
+
+	if dockercfg := os.Getenv("DOCKERCFG"); dockercfg != "" {
+		creds, err := readDockercfg(dockercfg)
+		if err != nil {
+			log.Fatalf("There was a problem with your .dockercfg: %v", err)
+		}
+		registryCredentials = creds
+	}
+
+	if interval := os.Getenv("ORPHAN_COLLECTION_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("There was a problem with your orphan collection interval: %v", err)
+		}
+		OrphanCollectionInterval = d
+	}
+
 	e, err := NewKubernetesExecutor(
 		os.Getenv("KUBERNETES_MASTER"),
 		os.Getenv("KUBERNETES_USERNAME"),
@@ -44,21 +133,47 @@ func init() {
 
 type KubernetesAdapter struct{}
 
+// ServiceDetail is the extended view of a deployed service, pairing its live
+// state with the Panamax definition it was created from. Definition is nil
+// for services that were deployed before definitions were being stored.
+// Warnings are any recent events explaining why a service isn't running.
+// Image is the normalized image its containers run, and Limits are the CPU
+// and memory they're held to, if any. PausedReplicas is what a paused service
+// will run again once it's resumed. Update is the canary or blue/green
+// update in progress, if there is one.
+type ServiceDetail struct {
+	pmxadapter.ServiceDeployment
+	DesiredReplicas int                 `json:"desiredReplicas"`
+	CurrentReplicas int                 `json:"currentReplicas"`
+	Application     string              `json:"application,omitempty"`
+	Definition      *pmxadapter.Service `json:"definition,omitempty"`
+	Warnings        []ServiceEvent      `json:"warnings,omitempty"`
+	Image           string              `json:"image,omitempty"`
+	ImagePullPolicy string              `json:"imagePullPolicy,omitempty"`
+	Limits          map[string]string   `json:"limits,omitempty"`
+	Update          *ServiceUpdate      `json:"update,omitempty"`
+	PausedReplicas  *int                `json:"pausedReplicas,omitempty"`
+}
+
 func (a KubernetesAdapter) GetServices() ([]pmxadapter.ServiceDeployment, error) {
 	rcs, err := DefaultExecutor.GetReplicationControllers()
 	if err != nil {
 		return []pmxadapter.ServiceDeployment{}, err
 	}
 
-	sds := make([]pmxadapter.ServiceDeployment, len(rcs))
-	for i, rc := range rcs {
+	sds := make([]pmxadapter.ServiceDeployment, 0, len(rcs))
+	for _, rc := range rcs {
+		// An update's new version belongs to the service being updated.
+		if isNextTrack(rc) {
+			continue
+		}
+
 		status, err := statusFromReplicationController(rc)
 		if err != nil {
 			return []pmxadapter.ServiceDeployment{}, err
 		}
 
-		sds[i].ID = rc.ObjectMeta.Name
-		sds[i].ActualState = status
+		sds = append(sds, pmxadapter.ServiceDeployment{ID: rc.ObjectMeta.Name, ActualState: status})
 	}
 	return sds, nil
 }
@@ -84,8 +199,71 @@ func (a KubernetesAdapter) GetService(id string) (pmxadapter.ServiceDeployment,
 	return sd, nil
 }
 
+func (a KubernetesAdapter) GetServiceDetail(id string) (ServiceDetail, error) {
+	rc, err := DefaultExecutor.GetReplicationController(id)
+	if err != nil {
+		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonNotFound {
+			return ServiceDetail{}, pmxadapter.NewNotFoundError(err.Error())
+		}
+
+		return ServiceDetail{}, err
+	}
+
+	status, err := statusFromReplicationController(rc)
+	if err != nil {
+		return ServiceDetail{}, err
+	}
+	definition, err := definitionFromReplicationController(rc)
+	if err != nil {
+		return ServiceDetail{}, err
+	}
+	// Warnings are only a hint at what's wrong, and not worth failing the
+	// rest of the detail over.
+	events, err := eventsForReplicationController(rc)
+	if err != nil {
+		log.Printf("Searching events for '%v' failed: %v", id, err)
+	}
+	update, err := updateFromReplicationController(rc)
+	if err != nil {
+		return ServiceDetail{}, err
+	}
+
+	sd := ServiceDetail{
+		ServiceDeployment: pmxadapter.ServiceDeployment{
+			ID:          rc.ObjectMeta.Name,
+			ActualState: status,
+		},
+		DesiredReplicas: rc.Spec.Replicas,
+		CurrentReplicas: rc.Status.Replicas,
+		Application:     rc.ObjectMeta.Labels[applicationLabel],
+		Definition:      definition,
+		Warnings:        recentWarnings(events),
+		Limits:          limitsFromReplicationController(rc),
+		Update:          update,
+	}
+	if rc.Spec.Template != nil && len(rc.Spec.Template.Spec.Containers) > 0 {
+		c := rc.Spec.Template.Spec.Containers[0]
+		sd.Image = c.Image
+		sd.ImagePullPolicy = string(c.ImagePullPolicy)
+	}
+	if replicas, err := strconv.Atoi(rc.ObjectMeta.Annotations[pausedAnnotation]); err == nil && isPaused(rc) {
+		sd.PausedReplicas = &replicas
+	}
+	return sd, nil
+}
+
 func (a KubernetesAdapter) DestroyService(id string) error {
-	err := DefaultExecutor.DeleteReplicationController(id)
+	return destroyService(id, false)
+}
+
+// ForceDestroyService is DestroyService for when pods don't terminate in
+// time: rather than giving up, it deletes them outright.
+func (a KubernetesAdapter) ForceDestroyService(id string) error {
+	return destroyService(id, true)
+}
+
+func destroyService(id string, force bool) error {
+	err := DefaultExecutor.DeleteReplicationController(id, force)
 	if err != nil {
 		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonNotFound {
 			return pmxadapter.NewNotFoundError(err.Error())
@@ -105,27 +283,58 @@ func (a KubernetesAdapter) GetMetadata() pmxadapter.Metadata {
 	}
 }
 
+// Pods only make a difference to a running service, so they're only fetched
+// then.
 func statusFromReplicationController(rc api.ReplicationController) (string, error) {
+	if isPaused(rc) || rc.Status.Replicas != rc.Spec.Replicas {
+		return statusFromPods(rc, nil), nil
+	}
+
+	selector := labels.OneTermEqualSelector("service-name", rc.ObjectMeta.Name)
+	pods, err := DefaultExecutor.GetPods(selector)
+	if err != nil {
+		return "", err
+	}
+
+	return statusFromPods(rc, pods), nil
+}
+
+// statusFromPods is statusFromReplicationController for when the service's
+// pods are already at hand.
+func statusFromPods(rc api.ReplicationController, pods []api.Pod) string {
+	if isPaused(rc) {
+		return "paused"
+	}
+
 	desired := rc.Spec.Replicas
 	actual := rc.Status.Replicas
-
 	if actual < desired {
-		return "pending", nil
-	} else if desired == actual {
-		selector := labels.OneTermEqualSelector("service-name", rc.ObjectMeta.Name)
-		pods, err := DefaultExecutor.GetPods(selector)
-		if err != nil {
-			return "", err
-		}
-		runningCount := 0
-		for _, p := range pods {
-			if p.Status.Phase == api.PodRunning {
-				runningCount++
-			}
+		return "pending"
+	} else if desired != actual {
+		return "unknown"
+	}
+
+	// An update's pods share the service-name label, but belong to the
+	// update's own ReplicationController.
+	runningCount := 0
+	for _, p := range pods {
+		if p.Status.Phase == api.PodRunning && p.ObjectMeta.Labels[trackLabel] != nextTrack {
+			runningCount++
 		}
+	}
+	return fmt.Sprintf("running %v/%v", runningCount, desired)
+}
+
+func definitionFromReplicationController(rc api.ReplicationController) (*pmxadapter.Service, error) {
+	encoded, exists := rc.ObjectMeta.Annotations[definitionAnnotation]
+	if !exists {
+		return nil, nil
+	}
 
-		return fmt.Sprintf("running %v/%v", runningCount, desired), nil
+	var s pmxadapter.Service
+	if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+		return nil, fmt.Errorf("unreadable definition for service '%v': %v", rc.ObjectMeta.Name, err)
 	}
 
-	return "unknown", nil
+	return &s, nil
 }
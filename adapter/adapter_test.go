@@ -2,13 +2,21 @@ package adapter
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/CenturyLinkLabs/pmxadapter"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/google/cadvisor/info"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,13 +26,52 @@ type TestExecutor struct {
 	Pods                 []api.Pod
 	CreatedSpec          api.ReplicationController
 	CreateRCError        error
+	ScaleError           error
+	ScaledID             string
+	ScaledReplicas       int
+	ScaleConverges       bool
+	UpdatedRCs           []api.ReplicationController
+	UpdatedPods          []api.Pod
+	DeletedPods          []string
+	ReplacePods          bool
 	CreateKServicesError error
 	GotPodsSelector      labels.Selector
 	GetPodsError         error
+	PodEvents            []watch.Event
+	WatchPodsError       error
+	PodWatches           int
+	RCEvents             []watch.Event
+	WatchRCsError        error
+	RCWatches            int
+	Logs                 map[string]string
+	LogStreams           map[string]io.ReadCloser
+	GotLogsRequests      []string
+	GetLogsError         error
+	Events               map[string][]api.Event
+	SearchEventsError    error
+	RunOutput            string
+	RunErrorOutput       string
+	RunError             error
+	RanTTY               bool
+	RanCommands          []string
+	ForwardedPorts       []string
+	Stats                map[string][]*info.ContainerStats
 	GetServicesError     error
 	GetServiceError      error
 	DeletionError        error
 	DestroyedServiceID   string
+	DestroyedServiceIDs  []string
+	DeletedKServices     []string
+	Secrets              []api.Secret
+	CreateSecretError    error
+	DeletedSecrets       []string
+	Nodes                []api.Node
+	GetNodesError        error
+	ResourceQuotas       []api.ResourceQuota
+	LimitRanges          []api.LimitRange
+	GotKServicesSelector labels.Selector
+	UpdatedKServices     []api.Service
+	DestroyForced        bool
 	HealthCheckResult    bool
 }
 
@@ -55,6 +102,101 @@ func (e *TestExecutor) GetPods(s labels.Selector) ([]api.Pod, error) {
 	return e.Pods, e.GetPodsError
 }
 
+// Every watch delivers PodEvents, and then stays open until it's stopped.
+func (e *TestExecutor) WatchPods(s labels.Selector, resourceVersion string) (watch.Interface, error) {
+	e.PodWatches++
+	if e.WatchPodsError != nil {
+		return nil, e.WatchPodsError
+	}
+
+	return newTestWatcher(e.PodEvents), nil
+}
+
+func (e *TestExecutor) WatchReplicationControllers(s labels.Selector, resourceVersion string) (watch.Interface, error) {
+	e.RCWatches++
+	if e.WatchRCsError != nil {
+		return nil, e.WatchRCsError
+	}
+
+	return newTestWatcher(e.RCEvents), nil
+}
+
+func newTestWatcher(events []watch.Event) *testWatcher {
+	w := &testWatcher{events: make(chan watch.Event, len(events))}
+	for _, event := range events {
+		w.events <- event
+	}
+	return w
+}
+
+type testWatcher struct {
+	events  chan watch.Event
+	stopped bool
+}
+
+func (w *testWatcher) Stop() {
+	if !w.stopped {
+		w.stopped = true
+		close(w.events)
+	}
+}
+
+func (w *testWatcher) ResultChan() <-chan watch.Event {
+	return w.events
+}
+
+func (e *TestExecutor) GetContainerLogs(host string, pod string, container string, tail string, follow bool) (io.ReadCloser, error) {
+	e.GotLogsRequests = append(e.GotLogsRequests, strings.Join([]string{host, pod, container, tail, fmt.Sprint(follow)}, " "))
+	if e.GetLogsError != nil {
+		return nil, e.GetLogsError
+	}
+
+	if r, exists := e.LogStreams[pod]; exists {
+		return r, nil
+	}
+	return ioutil.NopCloser(strings.NewReader(e.Logs[pod])), nil
+}
+
+// Anything sent to stdin is echoed back after RunOutput.
+func (e *TestExecutor) ExecInContainer(host string, pod string, container string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error {
+	e.RanCommands = append(e.RanCommands, strings.Join(append([]string{host, pod, container}, command...), " "))
+	e.RanTTY = tty
+	io.WriteString(stdout, e.RunOutput)
+	if stderr != nil && !tty {
+		io.WriteString(stderr, e.RunErrorOutput)
+	}
+	if stdin != nil {
+		io.Copy(stdout, stdin)
+	}
+	return e.RunError
+}
+
+func (e *TestExecutor) ForwardPorts(host string, pod string, ports []string, stop <-chan struct{}) error {
+	e.ForwardedPorts = append(e.ForwardedPorts, strings.Join(append([]string{host, pod}, ports...), " "))
+	return nil
+}
+
+func (e *TestExecutor) GetContainerStats(host string, pod string, container string) (*info.ContainerInfo, error) {
+	stats, exists := e.Stats[pod]
+	if !exists {
+		return nil, fmt.Errorf("no stats for %v on %v", pod, host)
+	}
+
+	return &info.ContainerInfo{Stats: stats}, nil
+}
+
+func (e *TestExecutor) SearchEvents(o runtime.Object) ([]api.Event, error) {
+	if e.SearchEventsError != nil {
+		return []api.Event{}, e.SearchEventsError
+	}
+
+	m, err := meta.Accessor(o)
+	if err != nil {
+		return []api.Event{}, err
+	}
+	return e.Events[m.Name()], nil
+}
+
 func (e *TestExecutor) CreateReplicationController(spec api.ReplicationController) (api.ReplicationController, error) {
 	e.CreatedSpec = spec
 
@@ -63,11 +205,79 @@ func (e *TestExecutor) CreateReplicationController(spec api.ReplicationControlle
 	}
 
 	spec.Status.Replicas = 0
+	e.RCs = append(e.RCs, spec)
 	return spec, nil
 }
 
-func (e *TestExecutor) DeleteReplicationController(id string) error {
+func (e *TestExecutor) ScaleReplicationController(id string, replicas int) (api.ReplicationController, error) {
+	e.ScaledID = id
+	e.ScaledReplicas = replicas
+	if e.ScaleError != nil {
+		return api.ReplicationController{}, e.ScaleError
+	}
+
+	for i := range e.RCs {
+		if e.RCs[i].ObjectMeta.Name == id {
+			e.RCs[i].Spec.Replicas = replicas
+			if e.ScaleConverges {
+				e.RCs[i].Status.Replicas = replicas
+			}
+			return e.RCs[i], nil
+		}
+	}
+
+	return api.ReplicationController{}, errors.New("Should never get here")
+}
+
+func (e *TestExecutor) UpdateReplicationController(rc api.ReplicationController) (api.ReplicationController, error) {
+	e.UpdatedRCs = append(e.UpdatedRCs, rc)
+	for i := range e.RCs {
+		if e.RCs[i].ObjectMeta.Name == rc.ObjectMeta.Name {
+			e.RCs[i] = rc
+			return rc, nil
+		}
+	}
+
+	return api.ReplicationController{}, errors.New("Should never get here")
+}
+
+func (e *TestExecutor) UpdatePod(p api.Pod) error {
+	e.UpdatedPods = append(e.UpdatedPods, p)
+	return nil
+}
+
+// With ReplacePods, a deleted pod is replaced by a running one labeled from
+// its RC's template, as a ReplicationController would.
+func (e *TestExecutor) DeletePod(name string) error {
+	e.DeletedPods = append(e.DeletedPods, name)
+	remaining := make([]api.Pod, 0, len(e.Pods))
+	for _, p := range e.Pods {
+		if p.ObjectMeta.Name != name {
+			remaining = append(remaining, p)
+			continue
+		}
+		if !e.ReplacePods {
+			continue
+		}
+
+		for _, rc := range e.RCs {
+			if rc.ObjectMeta.Name == p.ObjectMeta.Labels["service-name"] {
+				remaining = append(remaining, api.Pod{
+					ObjectMeta: api.ObjectMeta{Name: name + "-replacement", Labels: rc.Spec.Template.ObjectMeta.Labels},
+					Status:     api.PodStatus{Phase: api.PodRunning},
+				})
+			}
+		}
+	}
+
+	e.Pods = remaining
+	return nil
+}
+
+func (e *TestExecutor) DeleteReplicationController(id string, force bool) error {
 	e.DestroyedServiceID = id
+	e.DestroyedServiceIDs = append(e.DestroyedServiceIDs, id)
+	e.DestroyForced = force
 	if e.DeletionError != nil {
 		return e.DeletionError
 	}
@@ -75,8 +285,53 @@ func (e *TestExecutor) DeleteReplicationController(id string) error {
 }
 
 func (e *TestExecutor) CreateKServices(ks []api.Service) error {
-	e.KServices = ks
-	return e.CreateKServicesError
+	if e.CreateKServicesError != nil {
+		return e.CreateKServicesError
+	}
+
+	e.KServices = append(e.KServices, ks...)
+	return nil
+}
+
+func (e *TestExecutor) GetKServices(s labels.Selector) ([]api.Service, error) {
+	e.GotKServicesSelector = s
+	return e.KServices, nil
+}
+
+func (e *TestExecutor) UpdateKService(ks api.Service) error {
+	e.UpdatedKServices = append(e.UpdatedKServices, ks)
+	return nil
+}
+
+func (e *TestExecutor) DeleteKService(name string) error {
+	e.DeletedKServices = append(e.DeletedKServices, name)
+	return nil
+}
+
+func (e *TestExecutor) CreateSecret(s api.Secret) error {
+	e.Secrets = append(e.Secrets, s)
+	return e.CreateSecretError
+}
+
+func (e *TestExecutor) GetSecrets(s labels.Selector) ([]api.Secret, error) {
+	return e.Secrets, nil
+}
+
+func (e *TestExecutor) DeleteSecret(name string) error {
+	e.DeletedSecrets = append(e.DeletedSecrets, name)
+	return nil
+}
+
+func (e *TestExecutor) GetNodes() ([]api.Node, error) {
+	return e.Nodes, e.GetNodesError
+}
+
+func (e *TestExecutor) GetResourceQuotas() ([]api.ResourceQuota, error) {
+	return e.ResourceQuotas, nil
+}
+
+func (e *TestExecutor) GetLimitRanges() ([]api.LimitRange, error) {
+	return e.LimitRanges, nil
 }
 
 func (e *TestExecutor) IsHealthy() bool {
@@ -93,6 +348,16 @@ func adapterSetup() {
 	adapter = KubernetesAdapter{}
 	te = TestExecutor{}
 	DefaultExecutor = &te
+	tr = TestRegistryChecker{}
+	DefaultRegistryChecker = &tr
+	defaultLimits = api.ResourceList{}
+	secretPatterns = nil
+	registryCredentials = map[string]registryCredential{}
+	defaultPullPolicy = ""
+	policy = deploymentPolicy{}
+	defaultNodeSelector = map[string]string{}
+	operations = &operationStore{byID: map[string]*Operation{}}
+	states = newStateHub()
 }
 
 func TestSatisfiesAdapterInterface(t *testing.T) {
@@ -157,12 +422,70 @@ func TestErroredGetService(t *testing.T) {
 	assert.EqualError(t, err, "test error")
 }
 
+func TestSuccessfulGetServiceDetail(t *testing.T) {
+	setupRCs()
+	te.RCs[0].ObjectMeta.Annotations = map[string]string{
+		definitionAnnotation: `{"name":"Test Service","source":"redis","deployment":{"count":1}}`,
+	}
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", sd.ID)
+	assert.Equal(t, "pending", sd.ActualState)
+	assert.Equal(t, 1, sd.DesiredReplicas)
+	assert.Equal(t, 0, sd.CurrentReplicas)
+	if assert.NotNil(t, sd.Definition) {
+		assert.Equal(t, "Test Service", sd.Definition.Name)
+		assert.Equal(t, "redis", sd.Definition.Source)
+		assert.Equal(t, 1, sd.Definition.Deployment.Count)
+	}
+}
+
+func TestSuccessfulUndefinedGetServiceDetail(t *testing.T) {
+	setupRCs()
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", sd.ID)
+	assert.Nil(t, sd.Definition)
+}
+
+func TestErroredUnreadableGetServiceDetail(t *testing.T) {
+	setupRCs()
+	te.RCs[0].ObjectMeta.Annotations = map[string]string{definitionAnnotation: "{"}
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.Equal(t, ServiceDetail{}, sd)
+	assert.Contains(t, err.Error(), "unreadable definition for service 'test-service'")
+}
+
+func TestErroredNotFoundGetServiceDetail(t *testing.T) {
+	adapterSetup()
+	te.GetServiceError = kerrors.NewNotFound("thing", "name")
+	_, err := adapter.GetServiceDetail("UnknownID")
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+	}
+}
+
 func TestSuccessfulDestroyService(t *testing.T) {
 	setupRCs()
 	err := adapter.DestroyService("test-service")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "test-service", te.DestroyedServiceID)
+	assert.False(t, te.DestroyForced)
+}
+
+func TestSuccessfulForceDestroyService(t *testing.T) {
+	setupRCs()
+	err := adapter.ForceDestroyService("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", te.DestroyedServiceID)
+	assert.True(t, te.DestroyForced)
 }
 
 func TestErroredNotFoundDestroyService(t *testing.T) {
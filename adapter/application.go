@@ -0,0 +1,194 @@
+package adapter
+
+import (
+	"fmt"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// An ApplicationDeployment is the set of services that were created together
+// by a single CreateServices call, which Panamax always makes with a whole
+// template.
+type ApplicationDeployment struct {
+	ID          string                         `json:"id"`
+	ActualState string                         `json:"actualState"`
+	Services    []pmxadapter.ServiceDeployment `json:"services"`
+}
+
+func (a KubernetesAdapter) GetApplications() ([]ApplicationDeployment, error) {
+	rcs, err := DefaultExecutor.GetReplicationControllers()
+	if err != nil {
+		return []ApplicationDeployment{}, err
+	}
+
+	ids := make([]string, 0)
+	rcsByApp := map[string][]api.ReplicationController{}
+	for _, rc := range rcs {
+		id, exists := rc.ObjectMeta.Labels[applicationLabel]
+		if !exists {
+			continue
+		}
+		if _, seen := rcsByApp[id]; !seen {
+			ids = append(ids, id)
+		}
+		rcsByApp[id] = append(rcsByApp[id], rc)
+	}
+
+	ads := make([]ApplicationDeployment, len(ids))
+	for i, id := range ids {
+		ad, err := applicationFromReplicationControllers(id, rcsByApp[id])
+		if err != nil {
+			return []ApplicationDeployment{}, err
+		}
+		ads[i] = ad
+	}
+	return ads, nil
+}
+
+func (a KubernetesAdapter) GetApplication(id string) (ApplicationDeployment, error) {
+	rcs, err := applicationReplicationControllers(id)
+	if err != nil {
+		return ApplicationDeployment{}, err
+	}
+
+	return applicationFromReplicationControllers(id, rcs)
+}
+
+// DestroyApplication removes every ReplicationController and KService
+// created for an application. Services are destroyed before the services
+// they link to, so nothing is left running against a dependency that's
+// already gone.
+func (a KubernetesAdapter) DestroyApplication(id string) error {
+	rcs, err := applicationReplicationControllers(id)
+	if err != nil {
+		return err
+	}
+
+	ordered, err := destructionOrder(rcs)
+	if err != nil {
+		return err
+	}
+	for _, rc := range ordered {
+		if err := DefaultExecutor.DeleteReplicationController(rc.ObjectMeta.Name, false); err != nil {
+			return err
+		}
+	}
+
+	// Deleting an RC takes the KServices labeled for it, which covers the
+	// aliases pointing at it as well. This catches anything else.
+	forApp := labels.OneTermEqualSelector(applicationLabel, id)
+	kServices, err := DefaultExecutor.GetKServices(forApp)
+	if err != nil {
+		return err
+	}
+	for _, ks := range kServices {
+		if err := DefaultExecutor.DeleteKService(ks.ObjectMeta.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applicationReplicationControllers(id string) ([]api.ReplicationController, error) {
+	rcs, err := DefaultExecutor.GetReplicationControllers()
+	if err != nil {
+		return nil, err
+	}
+
+	appRCs := make([]api.ReplicationController, 0)
+	for _, rc := range rcs {
+		if rc.ObjectMeta.Labels[applicationLabel] == id {
+			appRCs = append(appRCs, rc)
+		}
+	}
+
+	if len(appRCs) == 0 {
+		return nil, pmxadapter.NewNotFoundError(fmt.Sprintf("application '%v' not found", id))
+	}
+	return appRCs, nil
+}
+
+func applicationFromReplicationControllers(id string, rcs []api.ReplicationController) (ApplicationDeployment, error) {
+	sds := make([]pmxadapter.ServiceDeployment, len(rcs))
+	for i, rc := range rcs {
+		status, err := statusFromReplicationController(rc)
+		if err != nil {
+			return ApplicationDeployment{}, err
+		}
+
+		sds[i].ID = rc.ObjectMeta.Name
+		sds[i].ActualState = status
+	}
+
+	ad := ApplicationDeployment{
+		ID:          id,
+		ActualState: applicationStatus(sds),
+		Services:    sds,
+	}
+	return ad, nil
+}
+
+// An application is running once all of its services are fully running, and
+// pending while any of them is. It's paused when all of them are. Anything
+// else is worth a human's attention.
+func applicationStatus(sds []pmxadapter.ServiceDeployment) string {
+	paused := 0
+	for _, sd := range sds {
+		if sd.ActualState == "paused" {
+			paused++
+		}
+	}
+	if paused > 0 && paused == len(sds) {
+		return "paused"
+	}
+
+	status := "running"
+	for _, sd := range sds {
+		var running, desired int
+		if _, err := fmt.Sscanf(sd.ActualState, "running %d/%d", &running, &desired); err == nil && running == desired {
+			continue
+		}
+		if sd.ActualState == "pending" {
+			return "pending"
+		}
+		status = "degraded"
+	}
+
+	return status
+}
+
+// Reverses the creation order implied by the stored definitions' links. RCs
+// without a definition have no known links and go first. Circular links have
+// no safe order, but that's no reason to refuse a destroy, so those fall back
+// to the order given.
+func destructionOrder(rcs []api.ReplicationController) ([]api.ReplicationController, error) {
+	rcsByName := map[string]api.ReplicationController{}
+	services := make([]*pmxadapter.Service, 0, len(rcs))
+	ordered := make([]api.ReplicationController, 0, len(rcs))
+	for _, rc := range rcs {
+		definition, err := definitionFromReplicationController(rc)
+		if err != nil {
+			return nil, err
+		}
+		if definition == nil {
+			ordered = append(ordered, rc)
+			continue
+		}
+
+		rcsByName[definition.Name] = rc
+		services = append(services, definition)
+	}
+
+	sorted, err := sortByDependencies(services)
+	if err != nil {
+		sorted = services
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ordered = append(ordered, rcsByName[sorted[i].Name])
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func applicationRC(name string, app string, definition string) api.ReplicationController {
+	rc := api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"service-name": name},
+		},
+		Spec:   api.ReplicationControllerSpec{Replicas: 1},
+		Status: api.ReplicationControllerStatus{Replicas: 1},
+	}
+	if app != "" {
+		rc.ObjectMeta.Labels[applicationLabel] = app
+	}
+	if definition != "" {
+		rc.ObjectMeta.Annotations = map[string]string{definitionAnnotation: definition}
+	}
+	return rc
+}
+
+func setupApplications() {
+	adapterSetup()
+	te.RCs = []api.ReplicationController{
+		applicationRC("web", "app-1", `{"name":"Web","links":[{"name":"DB","alias":"db"}]}`),
+		applicationRC("db", "app-1", `{"name":"DB"}`),
+		applicationRC("other", "app-2", `{"name":"Other"}`),
+		applicationRC("legacy", "", ""),
+	}
+	te.Pods = []api.Pod{{Status: api.PodStatus{Phase: api.PodRunning}}}
+}
+
+func TestSuccessfulGetApplications(t *testing.T) {
+	setupApplications()
+	ads, err := adapter.GetApplications()
+
+	assert.NoError(t, err)
+	if assert.Len(t, ads, 2) {
+		assert.Equal(t, "app-1", ads[0].ID)
+		assert.Equal(t, "running", ads[0].ActualState)
+		if assert.Len(t, ads[0].Services, 2) {
+			assert.Equal(t, "web", ads[0].Services[0].ID)
+			assert.Equal(t, "db", ads[0].Services[1].ID)
+		}
+		assert.Equal(t, "app-2", ads[1].ID)
+	}
+}
+
+func TestErroredGetApplications(t *testing.T) {
+	adapterSetup()
+	te.GetServicesError = errors.New("test error")
+	ads, err := adapter.GetApplications()
+
+	assert.Empty(t, ads)
+	assert.EqualError(t, err, "test error")
+}
+
+func TestSuccessfulGetApplication(t *testing.T) {
+	setupApplications()
+	ad, err := adapter.GetApplication("app-2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "app-2", ad.ID)
+	if assert.Len(t, ad.Services, 1) {
+		assert.Equal(t, "other", ad.Services[0].ID)
+	}
+}
+
+func TestErroredNotFoundGetApplication(t *testing.T) {
+	setupApplications()
+	_, err := adapter.GetApplication("missing")
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+		assert.Equal(t, "application 'missing' not found", pmxErr.Message)
+	}
+}
+
+func TestSuccessfulDestroyApplication(t *testing.T) {
+	setupApplications()
+	te.KServices = []api.Service{{ObjectMeta: api.ObjectMeta{Name: "straggler"}}}
+	err := adapter.DestroyApplication("app-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web", "db"}, te.DestroyedServiceIDs)
+	assert.Equal(t, "panamax-application=app-1", te.GotKServicesSelector.String())
+	assert.Equal(t, []string{"straggler"}, te.DeletedKServices)
+}
+
+func TestSuccessfulCircularDestroyApplication(t *testing.T) {
+	adapterSetup()
+	te.RCs = []api.ReplicationController{
+		applicationRC("a", "app", `{"name":"A","links":[{"name":"B"}]}`),
+		applicationRC("b", "app", `{"name":"B","links":[{"name":"A"}]}`),
+	}
+	err := adapter.DestroyApplication("app")
+
+	assert.NoError(t, err)
+	assert.Len(t, te.DestroyedServiceIDs, 2)
+}
+
+func TestErroredDestroyApplication(t *testing.T) {
+	setupApplications()
+	te.DeletionError = errors.New("test error")
+	err := adapter.DestroyApplication("app-1")
+
+	assert.EqualError(t, err, "test error")
+	assert.Empty(t, te.DeletedKServices)
+}
+
+func TestApplicationStatus(t *testing.T) {
+	running := pmxadapter.ServiceDeployment{ActualState: "running 2/2"}
+	partial := pmxadapter.ServiceDeployment{ActualState: "running 1/2"}
+	pending := pmxadapter.ServiceDeployment{ActualState: "pending"}
+
+	assert.Equal(t, "running", applicationStatus([]pmxadapter.ServiceDeployment{running, running}))
+	assert.Equal(t, "pending", applicationStatus([]pmxadapter.ServiceDeployment{running, partial, pending}))
+	assert.Equal(t, "degraded", applicationStatus([]pmxadapter.ServiceDeployment{running, partial}))
+}
@@ -1,16 +1,52 @@
 package adapter
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/CenturyLinkLabs/pmxadapter"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 func (a KubernetesAdapter) CreateServices(services []*pmxadapter.Service) ([]pmxadapter.ServiceDeployment, error) {
+	return a.CreateServicesWithOptions(services, CreateOptions{})
+}
+
+// CreateOptions change how a create goes about it. With Wait, it doesn't
+// return until every service has all of its pods running and ready, or until
+// Timeout passes, DefaultReadyTimeout if it's zero. With WaitForDependencies,
+// each service isn't created until the services it links to are ready, each
+// wait again taking at most Timeout. A progress func is told how each
+// service's ReplicationController creation went.
+type CreateOptions struct {
+	Wait                bool
+	WaitForDependencies bool
+	Timeout             time.Duration
+	progress            progressFunc
+}
+
+func (a KubernetesAdapter) CreateServicesWithOptions(services []*pmxadapter.Service, opts CreateOptions) ([]pmxadapter.ServiceDeployment, error) {
+	opts, err := checkCreateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkServiceNames(services); err != nil {
+		return nil, err
+	}
+
+	progress := opts.progress
+	if progress == nil {
+		progress = func(int, pmxadapter.ServiceDeployment, error) {}
+	}
+
 	deployments := make([]pmxadapter.ServiceDeployment, len(services))
 	// TODO destroy all services (and RCs I guess!) if there is an error
 	// anywhere. Otherwise they'll be orphaned and screw up subsequent deploys.
@@ -18,33 +54,188 @@ func (a KubernetesAdapter) CreateServices(services []*pmxadapter.Service) ([]pmx
 	if err != nil {
 		return nil, err
 	}
-	if err := DefaultExecutor.CreateKServices(kServices); err != nil {
-		return nil, err
+	// Services are created after the services they link to, so that they
+	// don't start up without them.
+	order, err := creationOrder(services)
+	if err != nil {
+		return nil, pmxadapter.NewError(http.StatusBadRequest, err.Error())
 	}
 
+	// Everything that can be rejected is, before anything gets created.
+	rcSpecs := make([]api.ReplicationController, len(services))
+	secrets := make([]*api.Secret, len(services))
 	for i, s := range services {
-		rcSpec := replicationControllerSpecFromService(*s)
-		rc, err := DefaultExecutor.CreateReplicationController(rcSpec)
-		if err != nil {
-			if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonAlreadyExists {
-				return nil, pmxadapter.NewAlreadyExistsError(err.Error())
+		if rcSpecs[i], err = replicationControllerSpecFromService(*s); err != nil {
+			return nil, err
+		}
+		if secrets[i], err = secretFromService(*s); err != nil {
+			return nil, err
+		}
+	}
+	if err := enforcePolicy(rcSpecs); err != nil {
+		return nil, err
+	}
+	if err := validateServicesImages(services); err != nil {
+		return nil, err
+	}
+	if err := validatePlacement(rcSpecs); err != nil {
+		return nil, err
+	}
+	if err := checkNamespaceLimits(rcSpecs, kServices); err != nil {
+		return nil, err
+	}
+
+	appID := newApplicationID()
+	for i := range kServices {
+		kServices[i].ObjectMeta.Labels[applicationLabel] = appID
+	}
+
+	forApp := labels.OneTermEqualSelector(applicationLabel, appID)
+	created := make([]api.ReplicationController, len(rcSpecs))
+	for _, i := range order {
+		if opts.WaitForDependencies {
+			if err := waitForDependencies(services, i, created, forApp, opts.Timeout); err != nil {
+				progress(i, pmxadapter.ServiceDeployment{}, err)
+				return nil, err
 			}
+		}
+
+		rc, err := createService(rcSpecs[i], secrets[i], kServices, appID)
+		if err != nil {
+			progress(i, pmxadapter.ServiceDeployment{}, err)
 			return nil, err
 		}
 
 		status, err := statusFromReplicationController(rc)
 		if err != nil {
+			progress(i, pmxadapter.ServiceDeployment{}, err)
 			return nil, err
 		}
 
 		deployments[i].ID = rc.ObjectMeta.Name
 		deployments[i].ActualState = status
+		progress(i, deployments[i], nil)
+		created[i] = rc
+	}
+
+	if !opts.Wait {
+		return deployments, nil
+	}
+	if err := waitForReady(forApp, created, opts.Timeout); err != nil {
+		return nil, err
+	}
+	for i, rc := range created {
+		if rc, err = DefaultExecutor.GetReplicationController(rc.ObjectMeta.Name); err != nil {
+			return nil, err
+		}
+		if deployments[i].ActualState, err = statusFromReplicationController(rc); err != nil {
+			return nil, err
+		}
+		progress(i, deployments[i], nil)
 	}
 
 	return deployments, nil
 }
 
-func replicationControllerSpecFromService(s pmxadapter.Service) api.ReplicationController {
+// createService creates everything a single service needs, its Secret first
+// since its pods can't start without it. A service's KServices, including the
+// aliases pointing at it, go up along with it: created any earlier, they'd sit
+// without their ReplicationController through the waits, looking like
+// orphans. The Secret isn't left behind if the service doesn't make it, as
+// it'd get in the way of deploying it again.
+func createService(rcSpec api.ReplicationController, secret *api.Secret, kServices []api.Service, appID string) (api.ReplicationController, error) {
+	if secret != nil {
+		secret.ObjectMeta.Labels[applicationLabel] = appID
+		if err := DefaultExecutor.CreateSecret(*secret); err != nil {
+			return api.ReplicationController{}, alreadyExistsError(err)
+		}
+	}
+
+	rc, err := createServiceObjects(rcSpec, kServices, appID)
+	if err != nil && secret != nil {
+		if dErr := DefaultExecutor.DeleteSecret(secret.ObjectMeta.Name); dErr != nil {
+			log.Printf("Deleting secret '%v' after a failed create failed: %v", secret.ObjectMeta.Name, dErr)
+		}
+	}
+
+	return rc, err
+}
+
+func createServiceObjects(rcSpec api.ReplicationController, kServices []api.Service, appID string) (api.ReplicationController, error) {
+	if ks := kServicesFor(kServices, rcSpec.ObjectMeta.Name); len(ks) > 0 {
+		if err := DefaultExecutor.CreateKServices(ks); err != nil {
+			return api.ReplicationController{}, err
+		}
+	}
+
+	rcSpec.ObjectMeta.Labels[applicationLabel] = appID
+	rcSpec.Spec.Template.ObjectMeta.Labels[applicationLabel] = appID
+	if err := recordRevision(&rcSpec); err != nil {
+		return api.ReplicationController{}, err
+	}
+	rc, err := DefaultExecutor.CreateReplicationController(rcSpec)
+	if err != nil {
+		return api.ReplicationController{}, alreadyExistsError(err)
+	}
+
+	return rc, nil
+}
+
+func alreadyExistsError(err error) error {
+	if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonAlreadyExists {
+		return pmxadapter.NewAlreadyExistsError(err.Error())
+	}
+	return err
+}
+
+// Each service is named for its sanitized name, and links are followed by
+// name, so no two services can share one.
+func checkServiceNames(services []*pmxadapter.Service) error {
+	names := map[string]string{}
+	for _, s := range services {
+		name := sanitizeServiceName(s.Name)
+		if other, exists := names[name]; exists {
+			msg := fmt.Sprintf("services '%v' and '%v' would both be named '%v'", other, s.Name, name)
+			return pmxadapter.NewError(http.StatusBadRequest, msg)
+		}
+		names[name] = s.Name
+	}
+
+	return nil
+}
+
+// The services that services[i] links to have all been created by the time
+// it's up, as it comes after them in creation order.
+func waitForDependencies(services []*pmxadapter.Service, i int, created []api.ReplicationController, selector labels.Selector, timeout time.Duration) error {
+	dependencies := make([]api.ReplicationController, 0)
+	for _, j := range linkedServices(services, i) {
+		dependencies = append(dependencies, created[j])
+	}
+	if len(dependencies) == 0 {
+		return nil
+	}
+
+	err := waitForReady(selector, dependencies, timeout)
+	if pmxErr, ok := err.(*pmxadapter.Error); ok {
+		msg := fmt.Sprintf("service '%v' wasn't started: %v", services[i].Name, pmxErr.Message)
+		return pmxadapter.NewError(pmxErr.Code, msg)
+	}
+	return err
+}
+
+func checkCreateOptions(opts CreateOptions) (CreateOptions, error) {
+	if opts.Timeout < 0 || opts.Timeout > MaxReadyTimeout {
+		msg := fmt.Sprintf("timeout must be at most %v", MaxReadyTimeout)
+		return opts, pmxadapter.NewError(http.StatusBadRequest, msg)
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultReadyTimeout
+	}
+
+	return opts, nil
+}
+
+func replicationControllerSpecFromService(s pmxadapter.Service) (api.ReplicationController, error) {
 	ports := make([]api.Port, len(s.Ports))
 	for i, p := range s.Ports {
 		ports[i].HostPort = int(p.HostPort)
@@ -52,10 +243,39 @@ func replicationControllerSpecFromService(s pmxadapter.Service) api.ReplicationC
 		ports[i].Protocol = api.Protocol(p.Protocol)
 	}
 
-	env := make([]api.EnvVar, len(s.Environment))
-	for i, e := range s.Environment {
-		env[i].Name = e.Variable
-		env[i].Value = e.Value
+	env := make([]api.EnvVar, 0, len(s.Environment))
+	hasSecrets := false
+	for _, e := range s.Environment {
+		if isExtensionVariable(e.Variable) {
+			continue
+		}
+		if isSecretVariable(s, e.Variable) {
+			env = append(env, secretFileVariable(e.Variable))
+			hasSecrets = true
+			continue
+		}
+		env = append(env, api.EnvVar{Name: e.Variable, Value: e.Value})
+	}
+
+	liveness, readiness, err := probesFromService(s)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	resources, err := resourcesFromService(s)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	image, err := parseImage(s.Source)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	pullPolicy, err := pullPolicyFromService(s, image)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	nodeSelector, host, err := placementFromService(s)
+	if err != nil {
+		return api.ReplicationController{}, err
 	}
 
 	safeName := sanitizeServiceName(s.Name)
@@ -72,9 +292,15 @@ func replicationControllerSpecFromService(s pmxadapter.Service) api.ReplicationC
 		replicas = 1
 	}
 
-	return api.ReplicationController{
+	// A pmxadapter.Service is nothing but strings, numbers and slices of the
+	// same, so it can't fail to marshal.
+	definition, _ := json.Marshal(redactSecrets(s))
+
+	rc := api.ReplicationController{
 		ObjectMeta: api.ObjectMeta{
-			Name: safeName,
+			Name:        safeName,
+			Labels:      map[string]string{"service-name": safeName},
+			Annotations: map[string]string{definitionAnnotation: string(definition)},
 		},
 		Spec: api.ReplicationControllerSpec{
 			Replicas: replicas,
@@ -87,19 +313,42 @@ func replicationControllerSpecFromService(s pmxadapter.Service) api.ReplicationC
 					},
 				},
 				Spec: api.PodSpec{
+					NodeSelector: nodeSelector,
+					Host:         host,
 					Containers: []api.Container{
 						{
 							Name:    safeName,
-							Image:   s.Source,
+							Image:   image.String(),
 							Command: commands,
 							Ports:   ports,
 							Env:     env,
+
+							ImagePullPolicy: pullPolicy,
+							Resources:       resources,
+							LivenessProbe:   liveness,
+							ReadinessProbe:  readiness,
 						},
 					},
 				},
 			},
 		},
 	}
+
+	if hasSecrets {
+		safeName := rc.ObjectMeta.Name
+		rc.Spec.Template.Spec.Volumes = []api.Volume{{
+			Name: secretsVolumeName,
+			Source: api.VolumeSource{
+				Secret: &api.SecretVolumeSource{
+					Target: api.ObjectReference{Kind: "Secret", Namespace: namespace, Name: secretName(safeName)},
+				},
+			},
+		}}
+		c := &rc.Spec.Template.Spec.Containers[0]
+		c.VolumeMounts = []api.VolumeMount{{Name: secretsVolumeName, ReadOnly: true, MountPath: secretsMountPath}}
+	}
+
+	return rc, nil
 }
 
 func kServicesFromServices(services []*pmxadapter.Service) ([]api.Service, error) {
@@ -160,6 +409,17 @@ func kServicesFromServices(services []*pmxadapter.Service) ([]api.Service, error
 	return kServices, nil
 }
 
+func kServicesFor(kServices []api.Service, name string) []api.Service {
+	matching := make([]api.Service, 0)
+	for _, ks := range kServices {
+		if ks.ObjectMeta.Labels["service-name"] == name {
+			matching = append(matching, ks)
+		}
+	}
+
+	return matching
+}
+
 // Once K8s allows multiple ports per service, we can lift the restriction on
 // a single port. We can't do anything about it now because we need to mimic
 // current Docker environment variables while satisfying K8s's requirement
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -14,6 +16,7 @@ var services []*pmxadapter.Service
 
 func servicesSetup() {
 	adapterSetup()
+	newApplicationID = func() string { return "test-app" }
 	services = []*pmxadapter.Service{
 		{
 			Name:        "Test Service",
@@ -33,12 +36,15 @@ func TestSuccessfulCreateServices(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "test-service", te.CreatedSpec.ObjectMeta.Name)
 	assert.Equal(t, 1, te.CreatedSpec.Spec.Replicas)
+	assert.Equal(t, "test-app", te.CreatedSpec.ObjectMeta.Labels[applicationLabel])
+	assert.Equal(t, "test-app", te.CreatedSpec.Spec.Template.ObjectMeta.Labels[applicationLabel])
 	if assert.Len(t, sd, 1) {
 		assert.Equal(t, "test-service", sd[0].ID)
 		assert.Equal(t, "pending", sd[0].ActualState)
 	}
 	if assert.Len(t, te.KServices, 1) {
 		assert.Equal(t, 31981, te.KServices[0].Spec.Port)
+		assert.Equal(t, "test-app", te.KServices[0].ObjectMeta.Labels[applicationLabel])
 	}
 }
 
@@ -84,11 +90,16 @@ func TestErroredConflictedCreateServices(t *testing.T) {
 
 func TestReplicationControllerFromService(t *testing.T) {
 	servicesSetup()
-	spec := replicationControllerSpecFromService(*services[0])
+	spec, err := replicationControllerSpecFromService(*services[0])
+	assert.NoError(t, err)
 
 	assert.Equal(t, "test-service", spec.ObjectMeta.Name)
 	assert.Equal(t, 1, spec.Spec.Replicas)
 
+	definition, err := definitionFromReplicationController(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, services[0], definition)
+
 	podTemplate := spec.Spec.Template
 	labels := podTemplate.ObjectMeta.Labels
 	assert.Equal(t, "test-service", labels["service-name"])
@@ -98,7 +109,8 @@ func TestReplicationControllerFromService(t *testing.T) {
 	if assert.Len(t, containers, 1) {
 		c := containers[0]
 		assert.Equal(t, "test-service", c.Name)
-		assert.Equal(t, "redis", c.Image)
+		assert.Equal(t, "redis:latest", c.Image)
+		assert.Equal(t, api.PullAlways, c.ImagePullPolicy)
 
 		if assert.Len(t, c.Command, 1) {
 			assert.Equal(t, "redis-server", c.Command[0])
@@ -120,7 +132,8 @@ func TestReplicationControllerFromService(t *testing.T) {
 func TestNoCommandReplicationControllerFromService(t *testing.T) {
 	servicesSetup()
 	services[0].Command = ""
-	spec := replicationControllerSpecFromService(*services[0])
+	spec, err := replicationControllerSpecFromService(*services[0])
+	assert.NoError(t, err)
 
 	containers := spec.Spec.Template.Spec.Containers
 	if assert.Len(t, containers, 1) {
@@ -267,3 +280,95 @@ func TestErroredMultiplePortsKServicesFromServices(t *testing.T) {
 	assert.Empty(t, kServices)
 	assert.Contains(t, err.Error(), "multiple ports")
 }
+
+func linkedServicesSetup() {
+	setupReady()
+	services = []*pmxadapter.Service{
+		{
+			Name:   "Web",
+			Source: "nginx",
+			Links:  []*pmxadapter.Link{{Name: "DB", Alias: "db"}},
+		},
+		{
+			Name:   "DB",
+			Source: "postgres",
+			Ports:  []*pmxadapter.Port{{HostPort: 5432, ContainerPort: 5432, Protocol: "TCP"}},
+		},
+	}
+}
+
+func TestSuccessfulOrderedCreateServices(t *testing.T) {
+	linkedServicesSetup()
+	sds, err := adapter.CreateServices(services)
+
+	assert.NoError(t, err)
+	if assert.Len(t, te.RCs, 2) {
+		assert.Equal(t, "db", te.RCs[0].ObjectMeta.Name)
+		assert.Equal(t, "web", te.RCs[1].ObjectMeta.Name)
+	}
+	if assert.Len(t, sds, 2) {
+		assert.Equal(t, "web", sds[0].ID)
+		assert.Equal(t, "db", sds[1].ID)
+	}
+	assert.Equal(t, 0, te.PodWatches)
+}
+
+func TestErroredCircularCreateServices(t *testing.T) {
+	linkedServicesSetup()
+	services[1].Links = []*pmxadapter.Link{{Name: "Web"}}
+	_, err := adapter.CreateServices(services)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		assert.Equal(t, "circular links between services: 'Web' -> 'DB' -> 'Web'", pmxErr.Message)
+	}
+	assert.Empty(t, te.RCs)
+	assert.Empty(t, te.KServices)
+}
+
+func TestErroredDuplicateNameCreateServices(t *testing.T) {
+	linkedServicesSetup()
+	services[1].Name = "web"
+	_, err := adapter.CreateServices(services)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		assert.Equal(t, "services 'Web' and 'web' would both be named 'web'", pmxErr.Message)
+	}
+	assert.Empty(t, te.RCs)
+	assert.Empty(t, te.KServices)
+}
+
+func TestSuccessfulWaitForDependenciesCreateServices(t *testing.T) {
+	linkedServicesSetup()
+	te.Pods = []api.Pod{{
+		ObjectMeta: api.ObjectMeta{Name: "db-pod", Labels: map[string]string{"service-name": "db"}},
+		Status:     api.PodStatus{Phase: api.PodRunning},
+	}}
+	_, err := adapter.CreateServicesWithOptions(services, CreateOptions{WaitForDependencies: true})
+
+	assert.NoError(t, err)
+	assert.Len(t, te.RCs, 2)
+	assert.Equal(t, 1, te.PodWatches)
+}
+
+func TestErroredWaitForDependenciesCreateServices(t *testing.T) {
+	linkedServicesSetup()
+	services[0].Ports = []*pmxadapter.Port{{HostPort: 8080, ContainerPort: 80, Protocol: "TCP"}}
+	_, err := adapter.CreateServicesWithOptions(services, CreateOptions{WaitForDependencies: true, Timeout: 20 * time.Millisecond})
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusGatewayTimeout, pmxErr.Code)
+		assert.Equal(t, "service 'Web' wasn't started: timed out after 20ms waiting for services to be ready: 'db' has 0 of 1 pods ready (no pods have been created)", pmxErr.Message)
+	}
+	if assert.Len(t, te.RCs, 1) {
+		assert.Equal(t, "db", te.RCs[0].ObjectMeta.Name)
+	}
+	assert.NotEmpty(t, te.KServices)
+	for _, ks := range te.KServices {
+		assert.Equal(t, "db", ks.ObjectMeta.Labels["service-name"])
+	}
+}
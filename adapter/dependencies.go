@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+)
+
+// sortByDependencies orders services so that each one comes after every
+// service it links to, keeping the original order wherever links allow it.
+// Links to services outside of the list are ignored here; they're caught by
+// the KService validations.
+func sortByDependencies(services []*pmxadapter.Service) ([]*pmxadapter.Service, error) {
+	byName := map[string]*pmxadapter.Service{}
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	sorted := make([]*pmxadapter.Service, 0, len(services))
+	path := make([]string, 0)
+
+	var visit func(s *pmxadapter.Service) error
+	visit = func(s *pmxadapter.Service) error {
+		switch state[s.Name] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := path
+			for i, name := range path {
+				if name == s.Name {
+					cycle = path[i:]
+					break
+				}
+			}
+			cycle = append(cycle, s.Name)
+			return fmt.Errorf("circular links between services: '%v'", strings.Join(cycle, "' -> '"))
+		}
+
+		state[s.Name] = visiting
+		path = append(path, s.Name)
+		for _, l := range s.Links {
+			if to, exists := byName[l.Name]; exists {
+				if err := visit(to); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[s.Name] = visited
+		sorted = append(sorted, s)
+
+		return nil
+	}
+
+	for _, s := range services {
+		if err := visit(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// creationOrder is sortByDependencies as indexes into services.
+func creationOrder(services []*pmxadapter.Service) ([]int, error) {
+	sorted, err := sortByDependencies(services)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := map[*pmxadapter.Service]int{}
+	for i, s := range services {
+		indexes[s] = i
+	}
+	order := make([]int, len(sorted))
+	for i, s := range sorted {
+		order[i] = indexes[s]
+	}
+
+	return order, nil
+}
+
+// linkedServices are the indexes of the services that services[i] links to,
+// each only once however many links there are to it.
+func linkedServices(services []*pmxadapter.Service, i int) []int {
+	indexes := map[string]int{}
+	for j, s := range services {
+		indexes[s.Name] = j
+	}
+
+	seen := map[int]bool{}
+	linked := make([]int, 0)
+	for _, l := range services[i].Links {
+		if j, exists := indexes[l.Name]; exists && !seen[j] {
+			seen[j] = true
+			linked = append(linked, j)
+		}
+	}
+
+	return linked
+}
@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func serviceNames(services []*pmxadapter.Service) []string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func TestSuccessfulSortByDependencies(t *testing.T) {
+	services := []*pmxadapter.Service{
+		{Name: "web", Links: []*pmxadapter.Link{{Name: "db"}, {Name: "cache"}}},
+		{Name: "worker", Links: []*pmxadapter.Link{{Name: "db"}}},
+		{Name: "cache"},
+		{Name: "db"},
+	}
+	sorted, err := sortByDependencies(services)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db", "cache", "web", "worker"}, serviceNames(sorted))
+}
+
+func TestSuccessfulUnknownLinkSortByDependencies(t *testing.T) {
+	services := []*pmxadapter.Service{
+		{Name: "web", Links: []*pmxadapter.Link{{Name: "elsewhere"}}},
+	}
+	sorted, err := sortByDependencies(services)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web"}, serviceNames(sorted))
+}
+
+func TestErroredCircularSortByDependencies(t *testing.T) {
+	services := []*pmxadapter.Service{
+		{Name: "lonely"},
+		{Name: "a", Links: []*pmxadapter.Link{{Name: "b"}}},
+		{Name: "b", Links: []*pmxadapter.Link{{Name: "c"}}},
+		{Name: "c", Links: []*pmxadapter.Link{{Name: "b"}}},
+	}
+	sorted, err := sortByDependencies(services)
+
+	assert.Nil(t, sorted)
+	assert.EqualError(t, err, "circular links between services: 'b' -> 'c' -> 'b'")
+}
+
+func TestSuccessfulCreationOrder(t *testing.T) {
+	services := []*pmxadapter.Service{
+		{Name: "web", Links: []*pmxadapter.Link{{Name: "db"}, {Name: "cache"}}},
+		{Name: "db"},
+		{Name: "cache"},
+	}
+	order, err := creationOrder(services)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 0}, order)
+}
+
+func TestLinkedServices(t *testing.T) {
+	services := []*pmxadapter.Service{
+		{Name: "web", Links: []*pmxadapter.Link{{Name: "db", Alias: "a"}, {Name: "db", Alias: "b"}, {Name: "elsewhere"}}},
+		{Name: "db"},
+	}
+
+	assert.Equal(t, []int{1}, linkedServices(services, 0))
+	assert.Empty(t, linkedServices(services, 1))
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// Warnings older than this are assumed to have been dealt with, one way or
+// another, and aren't surfaced with the service's status.
+const recentWarningWindow = 10 * time.Minute
+
+// A ServiceEvent is something Kubernetes recorded about a service's
+// ReplicationController or one of its pods, such as a scheduling failure or
+// an image pull error.
+type ServiceEvent struct {
+	Object    string    `json:"object"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Source    string    `json:"source"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// GetServiceEvents returns the events for a service's ReplicationController
+// and pods, oldest first.
+func (a KubernetesAdapter) GetServiceEvents(id string) ([]ServiceEvent, error) {
+	rc, err := DefaultExecutor.GetReplicationController(id)
+	if err != nil {
+		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonNotFound {
+			return []ServiceEvent{}, pmxadapter.NewNotFoundError(err.Error())
+		}
+
+		return []ServiceEvent{}, err
+	}
+
+	return eventsForReplicationController(rc)
+}
+
+func eventsForReplicationController(rc api.ReplicationController) ([]ServiceEvent, error) {
+	events, err := DefaultExecutor.SearchEvents(&rc)
+	if err != nil {
+		return []ServiceEvent{}, err
+	}
+
+	selector := labels.OneTermEqualSelector("service-name", rc.ObjectMeta.Name)
+	pods, err := DefaultExecutor.GetPods(selector)
+	if err != nil {
+		return []ServiceEvent{}, err
+	}
+	for i := range pods {
+		podEvents, err := DefaultExecutor.SearchEvents(&pods[i])
+		if err != nil {
+			return []ServiceEvent{}, err
+		}
+		events = append(events, podEvents...)
+	}
+
+	return serviceEventsFromEvents(events), nil
+}
+
+// Recent events that report something going wrong, most recent last.
+func recentWarnings(events []ServiceEvent) []ServiceEvent {
+	warnings := make([]ServiceEvent, 0)
+	for _, e := range events {
+		if isWarning(e) && time.Since(e.LastSeen) < recentWarningWindow {
+			warnings = append(warnings, e)
+		}
+	}
+
+	return warnings
+}
+
+// Events have no severity in this version of Kubernetes, but the components
+// that report problems all give reasons along the lines of "failed",
+// "failedScheduling" or "unhealthy".
+func isWarning(e ServiceEvent) bool {
+	r := strings.ToLower(e.Reason)
+	return strings.HasPrefix(r, "failed") || r == "unhealthy"
+}
+
+// The same event can turn up from more than one search, so they're
+// deduplicated by UID, or by everything else that identifies them when
+// there's no UID.
+func serviceEventsFromEvents(events []api.Event) []ServiceEvent {
+	seen := map[string]bool{}
+	ses := make([]ServiceEvent, 0, len(events))
+	for _, e := range events {
+		o := e.InvolvedObject
+		key := string(e.ObjectMeta.UID)
+		if key == "" {
+			key = fmt.Sprint(o.Kind, o.Name, o.FieldPath, e.Reason, e.Message, e.FirstTimestamp)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		object := fmt.Sprintf("%v/%v", o.Kind, o.Name)
+		if o.FieldPath != "" {
+			object = fmt.Sprintf("%v (%v)", object, o.FieldPath)
+		}
+		ses = append(ses, ServiceEvent{
+			Object:    object,
+			Reason:    e.Reason,
+			Message:   e.Message,
+			Source:    e.Source.Component,
+			Count:     e.Count,
+			FirstSeen: e.FirstTimestamp.Time,
+			LastSeen:  e.LastTimestamp.Time,
+		})
+	}
+
+	sort.Stable(byLastSeen(ses))
+	return ses
+}
+
+type byLastSeen []ServiceEvent
+
+func (s byLastSeen) Len() int           { return len(s) }
+func (s byLastSeen) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byLastSeen) Less(i, j int) bool { return s[i].LastSeen.Before(s[j].LastSeen) }
@@ -0,0 +1,110 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEvent(uid string, kind string, name string, reason string, lastSeen time.Time) api.Event {
+	return api.Event{
+		ObjectMeta:     api.ObjectMeta{UID: types.UID(uid)},
+		InvolvedObject: api.ObjectReference{Kind: kind, Name: name},
+		Reason:         reason,
+		Message:        reason + " happened",
+		Source:         api.EventSource{Component: "kubelet"},
+		Count:          1,
+		FirstTimestamp: util.NewTime(lastSeen),
+		LastTimestamp:  util.NewTime(lastSeen),
+	}
+}
+
+func setupEvents() {
+	setupRCs()
+	now := time.Now()
+	te.Pods = []api.Pod{{ObjectMeta: api.ObjectMeta{Name: "pod-a"}}}
+	pulled := testEvent("2", "Pod", "pod-a", "pulled", now.Add(-2*time.Minute))
+	te.Events = map[string][]api.Event{
+		"test-service": {testEvent("1", "ReplicationController", "test-service", "successfulCreate", now.Add(-3*time.Minute))},
+		"pod-a": {
+			testEvent("3", "Pod", "pod-a", "failed", now.Add(-time.Minute)),
+			pulled,
+			pulled,
+			testEvent("4", "Pod", "pod-a", "failedScheduling", now.Add(-time.Hour)),
+		},
+	}
+}
+
+func TestSuccessfulGetServiceEvents(t *testing.T) {
+	setupEvents()
+	events, err := adapter.GetServiceEvents("test-service")
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, "failedScheduling", events[0].Reason)
+		assert.Equal(t, "successfulCreate", events[1].Reason)
+		assert.Equal(t, "ReplicationController/test-service", events[1].Object)
+		assert.Equal(t, "pulled", events[2].Reason)
+		assert.Equal(t, "failed", events[3].Reason)
+		assert.Equal(t, "failed happened", events[3].Message)
+		assert.Equal(t, "kubelet", events[3].Source)
+	}
+}
+
+func TestSuccessfulNoUIDGetServiceEvents(t *testing.T) {
+	setupRCs()
+	e := testEvent("", "Pod", "pod-a", "pulled", time.Now())
+	te.Events = map[string][]api.Event{"test-service": {e, e}}
+	events, err := adapter.GetServiceEvents("test-service")
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestErroredNotFoundGetServiceEvents(t *testing.T) {
+	adapterSetup()
+	te.GetServiceError = kerrors.NewNotFound("thing", "name")
+	_, err := adapter.GetServiceEvents("test-service")
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+	}
+}
+
+func TestErroredGetServiceEvents(t *testing.T) {
+	setupEvents()
+	te.SearchEventsError = errors.New("test error")
+	events, err := adapter.GetServiceEvents("test-service")
+
+	assert.Empty(t, events)
+	assert.EqualError(t, err, "test error")
+}
+
+func TestSuccessfulWarningsGetServiceDetail(t *testing.T) {
+	setupEvents()
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	if assert.Len(t, sd.Warnings, 1) {
+		assert.Equal(t, "failed", sd.Warnings[0].Reason)
+	}
+}
+
+func TestErroredEventsGetServiceDetail(t *testing.T) {
+	setupEvents()
+	te.SearchEventsError = errors.New("test error")
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", sd.ID)
+	assert.Empty(t, sd.Warnings)
+}
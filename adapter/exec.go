@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+)
+
+// An ExecResult is what came back from running a command in one of a
+// service's containers.
+type ExecResult struct {
+	Pod    string `json:"pod"`
+	Output string `json:"output"`
+}
+
+// An Exec is a command ready to run in the container of one of a service's
+// running pods.
+type Exec struct {
+	Pod       string
+	host      string
+	container string
+	command   []string
+}
+
+// PrepareExec picks the pod that a command will run in, the named one if pod
+// isn't empty, so that anything wrong with the request is found before a
+// caller commits to streaming it.
+func (a KubernetesAdapter) PrepareExec(id string, pod string, command []string) (Exec, error) {
+	if len(command) == 0 {
+		return Exec{}, pmxadapter.NewError(http.StatusBadRequest, "command is required")
+	}
+
+	p, err := runningPod(id, pod)
+	if err != nil {
+		return Exec{}, err
+	}
+
+	return Exec{Pod: p.ObjectMeta.Name, host: p.Status.Host, container: id, command: command}, nil
+}
+
+// Stream runs the command, copying stdin to it and its output to stdout and
+// stderr until it exits. Either can be nil to leave that stream out, and with
+// a TTY the command's stderr goes to stdout.
+func (e Exec) Stream(stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error {
+	return DefaultExecutor.ExecInContainer(e.host, e.Pod, e.container, e.command, stdin, stdout, stderr, tty)
+}
+
+// ExecInService runs a command to completion in the container of one of a
+// service's running pods, the named one if pod isn't empty, and returns its
+// combined output.
+func (a KubernetesAdapter) ExecInService(id string, pod string, command []string) (ExecResult, error) {
+	e, err := a.PrepareExec(id, pod, command)
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	var out bytes.Buffer
+	w := &syncWriter{w: &out}
+	if err := e.Stream(nil, w, w, false); err != nil {
+		return ExecResult{}, err
+	}
+	return ExecResult{Pod: e.Pod, Output: out.String()}, nil
+}
+
+// A command's stdout and stderr are copied from streams of their own, side by
+// side, so writers they share need to take turns.
+type syncWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.Lock()
+	defer sw.Unlock()
+	return sw.w.Write(p)
+}
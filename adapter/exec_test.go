@@ -0,0 +1,85 @@
+package adapter
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupExec() {
+	setupRCs()
+	pending := logsPod("pod-a", "host-1")
+	pending.Status.Phase = api.PodPending
+	te.Pods = []api.Pod{pending, logsPod("pod-b", "host-2")}
+	te.RunOutput = "hello\n"
+}
+
+func TestSuccessfulExecInService(t *testing.T) {
+	setupExec()
+	result, err := adapter.ExecInService("test-service", "", []string{"echo", "hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExecResult{Pod: "pod-b", Output: "hello\n"}, result)
+	assert.Equal(t, []string{"host-2 pod-b test-service echo hello"}, te.RanCommands)
+	assert.False(t, te.RanTTY)
+}
+
+func TestSuccessfulStderrExecInService(t *testing.T) {
+	setupExec()
+	te.RunErrorOutput = "oops\n"
+	result, err := adapter.ExecInService("test-service", "", []string{"ls", "missing file"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\noops\n", result.Output)
+	assert.Equal(t, []string{"host-2 pod-b test-service ls missing file"}, te.RanCommands)
+}
+
+func TestErroredExecInService(t *testing.T) {
+	setupExec()
+	te.RunError = errors.New("test error")
+	_, err := adapter.ExecInService("test-service", "", []string{"ls"})
+
+	assert.EqualError(t, err, "test error")
+}
+
+func TestSuccessfulStreamExec(t *testing.T) {
+	setupExec()
+	e, err := adapter.PrepareExec("test-service", "", []string{"cat"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pod-b", e.Pod)
+
+		var out bytes.Buffer
+		err = e.Stream(strings.NewReader("typed\n"), &out, nil, true)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello\ntyped\n", out.String())
+		assert.Equal(t, []string{"host-2 pod-b test-service cat"}, te.RanCommands)
+		assert.True(t, te.RanTTY)
+	}
+}
+
+func TestErroredNoRunningPodsExecInService(t *testing.T) {
+	setupExec()
+	_, err := adapter.ExecInService("test-service", "pod-a", []string{"ls"})
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusConflict, pmxErr.Code)
+	}
+	assert.Empty(t, te.RanCommands)
+}
+
+func TestErroredNoCommandExecInService(t *testing.T) {
+	setupExec()
+	_, err := adapter.ExecInService("test-service", "", nil)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package adapter
+
+import (
+	"net/http"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+)
+
+// Panamax templates have no place for Kubernetes-specific settings, so they're
+// given as environment variables with this prefix. The adapter reads them and
+// keeps them out of the container's environment.
+const extensionPrefix = "PANAMAX_"
+
+// Templates may set other variables with the prefix for their containers, and
+// those are passed on like any other.
+var extensionVariables = map[string]bool{
+	livenessProbeVariable:  true,
+	readinessProbeVariable: true,
+	probeDelayVariable:     true,
+	probeTimeoutVariable:   true,
+	cpuLimitVariable:       true,
+	memoryLimitVariable:    true,
+	pullPolicyVariable:     true,
+	nodeSelectorVariable:   true,
+	hostVariable:           true,
+	secretsVariable:        true,
+}
+
+func isExtensionVariable(name string) bool {
+	return extensionVariables[name]
+}
+
+// extensionValue finds the value of a service's extension variable. Later
+// definitions win, as they would with Docker's -e.
+func extensionValue(s pmxadapter.Service, name string) (string, bool) {
+	value, exists := "", false
+	for _, e := range s.Environment {
+		if e.Variable == name {
+			value, exists = e.Value, true
+		}
+	}
+
+	return value, exists
+}
+
+func newExtensionError(msg string) error {
+	return pmxadapter.NewError(http.StatusBadRequest, msg)
+}
@@ -0,0 +1,540 @@
+package adapter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/codegangsta/martini"
+)
+
+// The same create as the standard API's, except that with async=true it only
+// starts the create, and answers with the Operation to follow it by. With
+// wait=true the create isn't done until the services are ready, and with
+// waitForDependencies=true each service waits for the services it links to.
+// Either waits at most timeout, a duration such as "90s".
+func createServices(a KubernetesAdapter, r *http.Request, w http.ResponseWriter) (int, string) {
+	q := r.URL.Query()
+	opts := CreateOptions{
+		Wait:                q.Get("wait") == "true",
+		WaitForDependencies: q.Get("waitForDependencies") == "true",
+	}
+	if timeout := q.Get("timeout"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Sprintf("invalid timeout: %v", err)
+		}
+		opts.Timeout = d
+	}
+
+	var services []*pmxadapter.Service
+	if err := json.NewDecoder(r.Body).Decode(&services); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	if q.Get("async") == "true" {
+		op, err := a.StartCreateServices(services, opts)
+		if err != nil {
+			return errorResponse(err)
+		}
+		w.Header().Set("Location", "/v1/operations/"+op.ID)
+		return encodeResponse(http.StatusAccepted, op)
+	}
+
+	sds, err := a.CreateServicesWithOptions(services, opts)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusCreated, sds)
+}
+
+func getServiceDetail(a KubernetesAdapter, params martini.Params) (int, string) {
+	sd, err := a.GetServiceDetail(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sd)
+}
+
+type scaleRequest struct {
+	Replicas *int `json:"replicas"`
+	Wait     bool `json:"wait"`
+}
+
+func scaleService(a KubernetesAdapter, params martini.Params, r *http.Request) (int, string) {
+	var req scaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if req.Replicas == nil {
+		return http.StatusBadRequest, "replicas is required"
+	}
+
+	sd, err := a.ScaleService(params["id"], *req.Replicas, req.Wait)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sd)
+}
+
+// Unlike the standard API's delete, a force=true query parameter deletes any
+// pods that don't terminate in time.
+func deleteService(a KubernetesAdapter, params martini.Params, r *http.Request) (int, string) {
+	var err error
+	if r.URL.Query().Get("force") == "true" {
+		err = a.ForceDestroyService(params["id"])
+	} else {
+		err = a.DestroyService(params["id"])
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return http.StatusNoContent, ""
+}
+
+func getApplications(a KubernetesAdapter) (int, string) {
+	ads, err := a.GetApplications()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, ads)
+}
+
+func getApplication(a KubernetesAdapter, params martini.Params) (int, string) {
+	ad, err := a.GetApplication(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, ad)
+}
+
+func deleteApplication(a KubernetesAdapter, params martini.Params) (int, string) {
+	if err := a.DestroyApplication(params["id"]); err != nil {
+		return errorResponse(err)
+	}
+
+	return http.StatusNoContent, ""
+}
+
+func pauseService(a KubernetesAdapter, params martini.Params) (int, string) {
+	sd, err := a.PauseService(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sd)
+}
+
+func resumeService(a KubernetesAdapter, params martini.Params) (int, string) {
+	sd, err := a.ResumeService(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sd)
+}
+
+func pauseApplication(a KubernetesAdapter, params martini.Params) (int, string) {
+	ad, err := a.PauseApplication(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, ad)
+}
+
+func resumeApplication(a KubernetesAdapter, params martini.Params) (int, string) {
+	ad, err := a.ResumeApplication(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, ad)
+}
+
+func getOperations(a KubernetesAdapter) (int, string) {
+	return encodeResponse(http.StatusOK, a.GetOperations())
+}
+
+func getOperation(a KubernetesAdapter, params martini.Params) (int, string) {
+	op, err := a.GetOperation(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, op)
+}
+
+func getOrphans(a KubernetesAdapter) (int, string) {
+	report, err := a.FindOrphans()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, report)
+}
+
+func deleteOrphans(a KubernetesAdapter) (int, string) {
+	report, err := a.CollectOrphans()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, report)
+}
+
+func getServiceEvents(a KubernetesAdapter, params martini.Params) (int, string) {
+	events, err := a.GetServiceEvents(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, events)
+}
+
+func getServiceMetrics(a KubernetesAdapter, params martini.Params) (int, string) {
+	sm, err := a.GetServiceMetrics(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sm)
+}
+
+type execRequest struct {
+	Command []string `json:"command"`
+	Pod     string   `json:"pod"`
+}
+
+// A request to upgrade to a raw TCP stream runs the command interactively,
+// with its arguments in the query since the body becomes its stdin. Anything
+// else runs it to completion from a JSON body.
+func execInService(a KubernetesAdapter, params martini.Params, r *http.Request, w http.ResponseWriter) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "tcp") {
+		execInteractively(a, params, r, w)
+		return
+	}
+
+	var req execRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var code int
+	var body string
+	result, err := a.ExecInService(params["id"], req.Pod, req.Command)
+	if err != nil {
+		code, body = errorResponse(err)
+	} else {
+		code, body = encodeResponse(http.StatusOK, result)
+	}
+
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+}
+
+// Once upgraded, the connection works like Docker's attach API: what the
+// client sends is the command's stdin, and its output comes back raw with a
+// TTY or otherwise in frames that tell stdout and stderr apart. The
+// connection is closed when the command exits.
+func execInteractively(a KubernetesAdapter, params martini.Params, r *http.Request, w http.ResponseWriter) {
+	q := r.URL.Query()
+	tty := q.Get("tty") == "true"
+	e, err := a.PrepareExec(params["id"], q.Get("pod"), q["command"])
+	if err != nil {
+		code, msg := errorResponse(err)
+		http.Error(w, msg, code)
+		return
+	}
+
+	conn, err := upgradeConnection(w)
+	if err != nil {
+		log.Printf("Upgrading exec connection for '%v' failed: %v", params["id"], err)
+		return
+	}
+	defer conn.Close()
+
+	var stdout, stderr io.Writer = conn, nil
+	if !tty {
+		out := &syncWriter{w: conn}
+		stdout, stderr = &frameWriter{w: out, stream: 1}, &frameWriter{w: out, stream: 2}
+	}
+	if err := e.Stream(conn, stdout, stderr, tty); err != nil {
+		log.Printf("Exec in pod '%v' failed: %v", e.Pod, err)
+		if stderr == nil {
+			stderr = stdout
+		}
+		fmt.Fprintln(stderr, err)
+	}
+}
+
+// upgradeConnection takes over the request's connection from the server,
+// answering it with 101 Switching Protocols.
+func upgradeConnection(w http.ResponseWriter) (net.Conn, error) {
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("the connection can't be taken over")
+	}
+
+	conn, buf, err := h.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &bufferedConn{Conn: conn, r: buf.Reader}, nil
+}
+
+// Whatever the client sent after its request may already have been read into
+// the server's buffer.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// A frameWriter prefixes what's written with a byte naming the stream, three
+// bytes of padding and the length as a big-endian uint32, as Docker does.
+type frameWriter struct {
+	w      io.Writer
+	stream byte
+}
+
+func (fw *frameWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	frame := make([]byte, 8, 8+len(p))
+	frame[0] = fw.stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(p)))
+	if _, err := fw.w.Write(append(frame, p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func getServiceRevisions(a KubernetesAdapter, params martini.Params) (int, string) {
+	revisions, err := a.GetServiceRevisions(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, revisions)
+}
+
+type rollbackRequest struct {
+	Revision *int `json:"revision"`
+}
+
+// Replacing pods one at a time can take minutes, so a rollback is only
+// started here and followed as an Operation.
+func rollbackService(a KubernetesAdapter, params martini.Params, r *http.Request, w http.ResponseWriter) (int, string) {
+	var req rollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if req.Revision == nil {
+		return http.StatusBadRequest, "revision is required"
+	}
+
+	op, err := a.StartRollbackService(params["id"], *req.Revision)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	w.Header().Set("Location", "/v1/operations/"+op.ID)
+	return encodeResponse(http.StatusAccepted, op)
+}
+
+func startServiceUpdate(a KubernetesAdapter, params martini.Params, r *http.Request) (int, string) {
+	var opts UpdateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	update, err := a.StartServiceUpdate(params["id"], opts)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusCreated, update)
+}
+
+func promoteServiceUpdate(a KubernetesAdapter, params martini.Params) (int, string) {
+	sd, err := a.PromoteServiceUpdate(params["id"])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return encodeResponse(http.StatusOK, sd)
+}
+
+func abortServiceUpdate(a KubernetesAdapter, params martini.Params) (int, string) {
+	if err := a.AbortServiceUpdate(params["id"]); err != nil {
+		return errorResponse(err)
+	}
+
+	return http.StatusNoContent, ""
+}
+
+// Logs are plain text, and are flushed as they're written so that followed
+// streams arrive as they happen. Once the first line has gone out the status
+// can't change, so later errors can only be logged.
+func getServiceLogs(a KubernetesAdapter, params martini.Params, r *http.Request, w http.ResponseWriter) {
+	q := r.URL.Query()
+	if q.Get("since") != "" {
+		http.Error(w, "since is not supported by this Kubernetes version's log API", http.StatusBadRequest)
+		return
+	}
+
+	opts := LogOptions{Pod: q.Get("pod"), Follow: q.Get("follow") == "true"}
+	if tail := q.Get("tail"); tail != "" {
+		t, err := strconv.Atoi(tail)
+		if err != nil {
+			http.Error(w, "tail must be a number of lines", http.StatusBadRequest)
+			return
+		}
+		opts.Tail = t
+	}
+	if cn, ok := w.(http.CloseNotifier); ok {
+		opts.Stop = cn.CloseNotify()
+	}
+
+	fw := &flushWriter{w: w}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := a.StreamServiceLogs(params["id"], opts, fw); err != nil {
+		if fw.written {
+			log.Printf("Streaming logs for '%v' failed: %v", params["id"], err)
+			return
+		}
+
+		code, msg := errorResponse(err)
+		http.Error(w, msg, code)
+	}
+}
+
+// How often an idle state stream sends a comment, so that proxies between it
+// and the client don't take it for dead.
+var keepAliveInterval = 30 * time.Second
+
+// State changes go out as server-sent events, with the cursor as the event ID
+// so that a reconnecting EventSource resumes where it left off by sending it
+// back as Last-Event-ID. Clients that can't set headers can use ?cursor=.
+func watchServices(a KubernetesAdapter, r *http.Request, w http.ResponseWriter) {
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("cursor")
+	}
+
+	sub, err := a.WatchServiceStates(cursor)
+	if err != nil {
+		code, msg := errorResponse(err)
+		http.Error(w, msg, code)
+		return
+	}
+	defer sub.Close()
+
+	var stop <-chan bool
+	if cn, ok := w.(http.CloseNotifier); ok {
+		stop = cn.CloseNotify()
+	}
+
+	fw := &flushWriter{w: w}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fw.Write([]byte(": watching services\n\n"))
+	for _, e := range sub.Initial {
+		if err := writeStateEvent(fw, e); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := writeStateEvent(fw, e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fw.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func writeStateEvent(w io.Writer, e ServiceStateEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", e.Cursor, e.Type, b)
+	return err
+}
+
+type flushWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	fw.written = true
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+func encodeResponse(code int, v interface{}) (int, string) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return code, string(b)
+}
+
+// Mirrors pmxadapter's handling so that errors look the same coming from
+// either server.
+func errorResponse(err error) (int, string) {
+	code := http.StatusInternalServerError
+	if pmxErr, ok := err.(*pmxadapter.Error); ok && http.StatusText(pmxErr.Code) != "" {
+		code = pmxErr.Code
+	}
+
+	return code, err.Error()
+}
@@ -0,0 +1,167 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessfulGetServiceDetailHandler(t *testing.T) {
+	setupRCs()
+	code, body := getServiceDetail(adapter, map[string]string{"id": "test-service"})
+
+	assert.Equal(t, http.StatusOK, code)
+	var sd ServiceDetail
+	if assert.NoError(t, json.Unmarshal([]byte(body), &sd)) {
+		assert.Equal(t, "test-service", sd.ID)
+		assert.Equal(t, "pending", sd.ActualState)
+	}
+}
+
+func TestErroredGetServiceDetailHandler(t *testing.T) {
+	adapterSetup()
+	te.GetServiceError = errors.New("test error")
+	code, body := getServiceDetail(adapter, map[string]string{"id": "test-service"})
+
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "test error", body)
+}
+
+func TestSuccessfulScaleServiceHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("PUT", "http://localhost", strings.NewReader(`{"replicas": 3}`))
+	code, _ := scaleService(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, te.ScaledReplicas)
+}
+
+func TestErroredMissingReplicasScaleServiceHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("PUT", "http://localhost", strings.NewReader(`{}`))
+	code, body := scaleService(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "replicas is required", body)
+}
+
+func TestErroredBadJSONScaleServiceHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("PUT", "http://localhost", strings.NewReader("BAD JSON"))
+	code, body := scaleService(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "invalid character")
+}
+
+func TestSuccessfulDeleteServiceHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("DELETE", "http://localhost", nil)
+	code, _ := deleteService(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, "test-service", te.DestroyedServiceID)
+	assert.False(t, te.DestroyForced)
+}
+
+func TestSuccessfulForcedDeleteServiceHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("DELETE", "http://localhost?force=true", nil)
+	code, _ := deleteService(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.True(t, te.DestroyForced)
+}
+
+func TestSuccessfulGetApplicationsHandler(t *testing.T) {
+	setupApplications()
+	code, body := getApplications(adapter)
+
+	assert.Equal(t, http.StatusOK, code)
+	var ads []ApplicationDeployment
+	if assert.NoError(t, json.Unmarshal([]byte(body), &ads)) {
+		assert.Len(t, ads, 2)
+	}
+}
+
+func TestErroredNotFoundDeleteApplicationHandler(t *testing.T) {
+	setupApplications()
+	code, _ := deleteApplication(adapter, map[string]string{"id": "missing"})
+
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Empty(t, te.DestroyedServiceIDs)
+}
+
+func TestSuccessfulGetServiceLogsHandler(t *testing.T) {
+	setupLogs()
+	r, _ := http.NewRequest("GET", "http://localhost?tail=5&pod=pod-b", nil)
+	w := httptest.NewRecorder()
+	getServiceLogs(adapter, map[string]string{"id": "test-service"}, r, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "b one", w.Body.String())
+	assert.Equal(t, []string{"host-2 pod-b test-service 5 false"}, te.GotLogsRequests)
+}
+
+func TestErroredSinceGetServiceLogsHandler(t *testing.T) {
+	setupLogs()
+	r, _ := http.NewRequest("GET", "http://localhost?since=2015-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	getServiceLogs(adapter, map[string]string{"id": "test-service"}, r, w)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, te.GotLogsRequests)
+}
+
+func TestErroredNotFoundGetServiceLogsHandler(t *testing.T) {
+	setupRCs()
+	r, _ := http.NewRequest("GET", "http://localhost", nil)
+	w := httptest.NewRecorder()
+	getServiceLogs(adapter, map[string]string{"id": "test-service"}, r, w)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestErrorResponse(t *testing.T) {
+	code, _ := errorResponse(pmxadapter.NewNotFoundError("missing"))
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = errorResponse(pmxadapter.NewError(9090, "odd"))
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
+
+func TestSuccessfulStartServiceUpdateHandler(t *testing.T) {
+	setupUpdate()
+	r, _ := http.NewRequest("POST", "http://localhost", strings.NewReader(`{"strategy": "canary", "image": "redis:3.0"}`))
+	code, body := startServiceUpdate(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusCreated, code)
+	var update ServiceUpdate
+	if assert.NoError(t, json.Unmarshal([]byte(body), &update)) {
+		assert.Equal(t, CanaryStrategy, update.Strategy)
+		assert.Equal(t, 1, update.Replicas)
+	}
+}
+
+func TestErroredBadJSONStartServiceUpdateHandler(t *testing.T) {
+	setupUpdate()
+	r, _ := http.NewRequest("POST", "http://localhost", strings.NewReader("BAD JSON"))
+	code, body := startServiceUpdate(adapter, map[string]string{"id": "test-service"}, r)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "invalid character")
+}
+
+func TestSuccessfulAbortServiceUpdateHandler(t *testing.T) {
+	setupUpdate()
+	startUpdate(t, CanaryStrategy)
+	code, _ := abortServiceUpdate(adapter, map[string]string{"id": "test-service"})
+
+	assert.Equal(t, http.StatusNoContent, code)
+}
@@ -0,0 +1,134 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+const (
+	pullPolicyVariable = extensionPrefix + "IMAGE_PULL_POLICY"
+	maxImageNameLength = 255
+)
+
+var (
+	// defaultPullPolicy, from IMAGE_PULL_POLICY, overrides the choice based on
+	// the image's tag for every service.
+	defaultPullPolicy api.PullPolicy
+
+	registryPattern   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?$`)
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*([-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// An imageReference is a parsed image name. Images on Docker Hub have no
+// Registry, and an image pinned by Digest has no Tag.
+type imageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImage reads an image reference of the form
+// [registry/]repository[:tag][@digest], defaulting the tag to latest.
+func parseImage(image string) (imageReference, error) {
+	var ref imageReference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestPattern.MatchString(ref.Digest) {
+			return imageReference{}, invalidImageError(image, "invalid digest")
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagPattern.MatchString(ref.Tag) {
+			return imageReference{}, invalidImageError(image, "invalid tag")
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		name = parts[1]
+		if !registryPattern.MatchString(ref.Registry) {
+			return imageReference{}, invalidImageError(image, "invalid registry")
+		}
+	}
+
+	if len(name) > maxImageNameLength || !repositoryPattern.MatchString(name) {
+		return imageReference{}, invalidImageError(image, "invalid repository name")
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+func invalidImageError(image string, reason string) error {
+	return pmxadapter.NewError(http.StatusBadRequest, fmt.Sprintf("image '%v' is malformed: %v", image, reason))
+}
+
+// String is the normalized reference, with the tag always spelled out.
+func (r imageReference) String() string {
+	s := r.Repository
+	if r.Registry != "" {
+		s = r.Registry + "/" + s
+	}
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+
+	return s
+}
+
+// Reference is the tag or digest to ask a registry for.
+func (r imageReference) Reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// pullPolicyFromService takes PANAMAX_IMAGE_PULL_POLICY, then the adapter's
+// IMAGE_PULL_POLICY. Failing those, latest is always pulled, since it may
+// have moved, and anything pinned is only pulled when it's not on the node.
+func pullPolicyFromService(s pmxadapter.Service, ref imageReference) (api.PullPolicy, error) {
+	if value, exists := extensionValue(s, pullPolicyVariable); exists {
+		policy, err := parsePullPolicy(value)
+		if err != nil {
+			return "", newExtensionError(fmt.Sprintf("%v %v", pullPolicyVariable, err))
+		}
+		return policy, nil
+	}
+
+	if defaultPullPolicy != "" {
+		return defaultPullPolicy, nil
+	}
+	if ref.Digest == "" && ref.Tag == "latest" {
+		return api.PullAlways, nil
+	}
+	return api.PullIfNotPresent, nil
+}
+
+func parsePullPolicy(value string) (api.PullPolicy, error) {
+	for _, p := range []api.PullPolicy{api.PullAlways, api.PullIfNotPresent, api.PullNever} {
+		if strings.EqualFold(value, string(p)) {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("must be Always, IfNotPresent or Never, not '%v'", value)
+}
@@ -0,0 +1,121 @@
+package adapter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDigest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+func TestSuccessfulParseImage(t *testing.T) {
+	for image, expected := range map[string]imageReference{
+		"redis":                              {Repository: "redis", Tag: "latest"},
+		"library/redis:2.8":                  {Repository: "library/redis", Tag: "2.8"},
+		"registry.example.com/team/app":      {Registry: "registry.example.com", Repository: "team/app", Tag: "latest"},
+		"registry.example.com:5000/app:v1.2": {Registry: "registry.example.com:5000", Repository: "app", Tag: "v1.2"},
+		"localhost/app:dev":                  {Registry: "localhost", Repository: "app", Tag: "dev"},
+		"redis@" + testDigest:                {Repository: "redis", Digest: testDigest},
+	} {
+		ref, err := parseImage(image)
+		assert.NoError(t, err, image)
+		assert.Equal(t, expected, ref, image)
+	}
+}
+
+func TestErroredParseImage(t *testing.T) {
+	for _, image := range []string{
+		"",
+		"Redis",
+		"redis:",
+		"redis:-tag",
+		"redis@sha256:short",
+		"bad_host.example.com/app",
+		"team//app",
+		strings.Repeat("a", 256),
+	} {
+		_, err := parseImage(image)
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr, image) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+			assert.Contains(t, pmxErr.Message, "is malformed")
+		}
+	}
+}
+
+func TestImageReferenceString(t *testing.T) {
+	for image, expected := range map[string]string{
+		"redis":                       "redis:latest",
+		"registry.example.com/app:v1": "registry.example.com/app:v1",
+		"redis@" + testDigest:         "redis@" + testDigest,
+		"redis:2.8@" + testDigest:     "redis:2.8@" + testDigest,
+	} {
+		ref, err := parseImage(image)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ref.String())
+	}
+}
+
+func TestPullPolicyFromService(t *testing.T) {
+	servicesSetup()
+	for image, expected := range map[string]api.PullPolicy{
+		"redis":               api.PullAlways,
+		"redis:latest":        api.PullAlways,
+		"redis:2.8":           api.PullIfNotPresent,
+		"redis@" + testDigest: api.PullIfNotPresent,
+	} {
+		ref, _ := parseImage(image)
+		policy, err := pullPolicyFromService(*services[0], ref)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, policy, image)
+	}
+}
+
+func TestConfiguredPullPolicyFromService(t *testing.T) {
+	servicesSetup()
+	defaultPullPolicy = api.PullNever
+	ref, _ := parseImage("redis")
+
+	policy, err := pullPolicyFromService(*services[0], ref)
+	assert.NoError(t, err)
+	assert.Equal(t, api.PullNever, policy)
+
+	s := withEnvironment(*services[0], "PANAMAX_IMAGE_PULL_POLICY", "ifnotpresent")
+	policy, err = pullPolicyFromService(s, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, api.PullIfNotPresent, policy)
+
+	s = withEnvironment(*services[0], "PANAMAX_IMAGE_PULL_POLICY", "Sometimes")
+	_, err = pullPolicyFromService(s, ref)
+	assert.Error(t, err)
+}
+
+func TestErroredMalformedImageCreateServices(t *testing.T) {
+	servicesSetup()
+	services[0].Source = "Not An Image"
+	sd, err := adapter.CreateServices(services)
+
+	assert.Empty(t, sd)
+	assert.Error(t, err)
+	assert.Empty(t, te.KServices)
+	assert.Empty(t, tr.CheckedImages)
+}
+
+func TestImageGetServiceDetail(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Template = &api.PodTemplateSpec{
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "redis:latest", ImagePullPolicy: api.PullAlways}},
+		},
+	}
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "redis:latest", sd.Image)
+	assert.Equal(t, "Always", sd.ImagePullPolicy)
+}
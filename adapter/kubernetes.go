@@ -1,9 +1,23 @@
 package adapter
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/portforward"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/remotecommand"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/google/cadvisor/info"
 )
 
 const (
@@ -11,28 +25,80 @@ const (
 	multiplePortsError = "multiple ports from a single container is not currently supported"
 )
 
+var (
+	terminationTimeout = time.Minute
+	kubeletPort        = 10250
+	// A kubelet that stops answering would otherwise hold up every request
+	// for metrics.
+	kubeletClient = &http.Client{Timeout: 10 * time.Second}
+)
+
 type Executor interface {
 	GetReplicationControllers() ([]api.ReplicationController, error)
 	GetReplicationController(string) (api.ReplicationController, error)
+	WatchReplicationControllers(s labels.Selector, resourceVersion string) (watch.Interface, error)
 	GetPods(labels.Selector) ([]api.Pod, error)
+	WatchPods(s labels.Selector, resourceVersion string) (watch.Interface, error)
+	GetContainerLogs(host string, pod string, container string, tail string, follow bool) (io.ReadCloser, error)
+	SearchEvents(runtime.Object) ([]api.Event, error)
+	ExecInContainer(host string, pod string, container string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error
+	GetContainerStats(host string, pod string, container string) (*info.ContainerInfo, error)
+	ForwardPorts(host string, pod string, ports []string, stop <-chan struct{}) error
 	CreateReplicationController(api.ReplicationController) (api.ReplicationController, error)
-	DeleteReplicationController(string) error
+	ScaleReplicationController(string, int) (api.ReplicationController, error)
+	UpdateReplicationController(api.ReplicationController) (api.ReplicationController, error)
+	DeleteReplicationController(string, bool) error
+	UpdatePod(api.Pod) error
+	DeletePod(string) error
 	CreateKServices([]api.Service) error
+	GetKServices(labels.Selector) ([]api.Service, error)
+	UpdateKService(api.Service) error
+	DeleteKService(string) error
+	CreateSecret(api.Secret) error
+	GetSecrets(labels.Selector) ([]api.Secret, error)
+	DeleteSecret(string) error
+	GetNodes() ([]api.Node, error)
+	GetResourceQuotas() ([]api.ResourceQuota, error)
+	GetLimitRanges() ([]api.LimitRange, error)
 	IsHealthy() bool
 }
 
 type KubernetesExecutor struct {
-	client *client.Client
+	client        *client.Client
+	config        *client.Config
+	streamClient  *client.RESTClient
+	containerInfo client.ContainerInfoGetter
 }
 
 func NewKubernetesExecutor(url string, username string, password string) (Executor, error) {
 	config := client.Config{Host: url, Username: username, Password: password}
-	client, err := client.New(&config)
+	c, err := client.New(&config)
 	if err != nil {
 		return KubernetesExecutor{}, err
 	}
 
-	return KubernetesExecutor{client: client}, nil
+	// Request.Stream hands back whatever the server answers, error statuses
+	// included, so streams are requested through a client that checks.
+	streamClient := *c.RESTClient
+	httpClient := streamClient.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	streamClient.Client = client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, fmt.Errorf("%v %v: %v", req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+		}
+		return resp, nil
+	})
+
+	containerInfo := &client.HTTPContainerInfoGetter{Client: kubeletClient, Port: kubeletPort}
+	return KubernetesExecutor{client: c, config: &config, streamClient: &streamClient, containerInfo: containerInfo}, nil
 }
 
 func (k KubernetesExecutor) GetReplicationControllers() ([]api.ReplicationController, error) {
@@ -58,6 +124,62 @@ func (k KubernetesExecutor) GetPods(s labels.Selector) ([]api.Pod, error) {
 	return ps.Items, err
 }
 
+// Logs come from the kubelet on the pod's host, by way of the API server's
+// proxy.
+func (k KubernetesExecutor) GetContainerLogs(host string, pod string, container string, tail string, follow bool) (io.ReadCloser, error) {
+	return k.streamClient.Get().
+		Prefix("proxy").
+		Resource("minions").
+		Name(host).
+		Suffix("containerLogs", namespace, pod, container).
+		Param("tail", tail).
+		Param("follow", strconv.FormatBool(follow)).
+		Stream()
+}
+
+// Commands run over a SPDY stream that the API server proxies to the pod's
+// kubelet, with streams of their own for stdin, stdout and stderr.
+func (k KubernetesExecutor) ExecInContainer(host string, pod string, container string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error {
+	req := k.client.Post().
+		Prefix("proxy").
+		Resource("minions").
+		Name(host).
+		Suffix("exec", namespace, pod, container)
+
+	return remotecommand.New(req, k.config, command, stdin, stdout, stderr, tty).Execute()
+}
+
+// Stats come straight from the kubelet on the pod's host. Two samples are
+// enough to work out a rate of CPU usage.
+func (k KubernetesExecutor) GetContainerStats(host string, pod string, container string) (*info.ContainerInfo, error) {
+	return k.containerInfo.GetContainerInfo(host, pod, container, &info.ContainerInfoRequest{NumStats: 2})
+}
+
+// Forwarded ports listen on localhost, with connections carried to the pod
+// over a SPDY stream that the API server proxies to its kubelet.
+func (k KubernetesExecutor) ForwardPorts(host string, pod string, ports []string, stop <-chan struct{}) error {
+	req := k.client.Post().
+		Prefix("proxy").
+		Resource("minions").
+		Name(host).
+		Suffix("portForward", namespace, pod)
+
+	pf, err := portforward.New(req, k.config, ports, stop)
+	if err != nil {
+		return err
+	}
+	return pf.ForwardPorts()
+}
+
+func (k KubernetesExecutor) SearchEvents(o runtime.Object) ([]api.Event, error) {
+	el, err := k.client.Events(namespace).Search(o)
+	if err != nil {
+		return []api.Event{}, err
+	}
+
+	return el.Items, nil
+}
+
 func (k KubernetesExecutor) CreateReplicationController(spec api.ReplicationController) (api.ReplicationController, error) {
 	rc, err := k.client.ReplicationControllers(namespace).Create(&spec)
 	if err != nil {
@@ -67,7 +189,31 @@ func (k KubernetesExecutor) CreateReplicationController(spec api.ReplicationCont
 	return *rc, nil
 }
 
-func (k KubernetesExecutor) DeleteReplicationController(id string) error {
+func (k KubernetesExecutor) ScaleReplicationController(id string, replicas int) (api.ReplicationController, error) {
+	rc, err := k.GetReplicationController(id)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	rc.Spec.Replicas = replicas
+	updated, err := k.client.ReplicationControllers(namespace).Update(&rc)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	return *updated, nil
+}
+
+func (k KubernetesExecutor) UpdateReplicationController(rc api.ReplicationController) (api.ReplicationController, error) {
+	updated, err := k.client.ReplicationControllers(namespace).Update(&rc)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	return *updated, nil
+}
+
+func (k KubernetesExecutor) DeleteReplicationController(id string, force bool) error {
 	// Maybe find the desired ReplicationController
 	rc, err := k.GetReplicationController(id)
 	if err != nil {
@@ -88,21 +234,114 @@ func (k KubernetesExecutor) DeleteReplicationController(id string) error {
 		}
 	}
 
+	// Take down any ReplicationController running alongside it during an
+	// update first, since its Pods share the service's label
+	rl, err := k.client.ReplicationControllers(namespace).List(forService)
+	if err != nil {
+		return err
+	}
+	for _, companion := range rl.Items {
+		if companion.ObjectMeta.Name == rc.ObjectMeta.Name {
+			continue
+		}
+		if err := k.scaleDownAndDelete(companion, force); err != nil {
+			return err
+		}
+	}
+
+	if err := k.scaleDownAndDelete(rc, force); err != nil {
+		return err
+	}
+
+	// Delete the Secrets its pods were using, now that nothing is left
+	// running that could need them.
+	secrets, err := k.GetSecrets(forService)
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets {
+		if err := k.DeleteSecret(s.ObjectMeta.Name); err != nil {
+			return err
+		}
+	}
+
+	// Profit?
+	return nil
+}
+
+func (k KubernetesExecutor) scaleDownAndDelete(rc api.ReplicationController, force bool) error {
 	// Scale down the ReplicationController, deleting Pods
 	rc.Spec.Replicas = 0
 	if _, err := k.client.ReplicationControllers(namespace).Update(&rc); err != nil {
 		return err
 	}
 
+	// Wait for the Pods to go away, so that a redeploy under the same name
+	// doesn't race them for host ports
+	selector := labels.SelectorFromSet(labels.Set(rc.Spec.Selector))
+	if err := k.waitForPodTermination(selector, force); err != nil {
+		return err
+	}
+
 	// Delete the ReplicationController
-	if err := k.client.ReplicationControllers(namespace).Delete(id); err != nil {
+	return k.client.ReplicationControllers(namespace).Delete(rc.ObjectMeta.Name)
+}
+
+// Stragglers are only deleted outright when forced, otherwise running out of
+// time is an error and the ReplicationController is left scaled to zero.
+func (k KubernetesExecutor) waitForPodTermination(s labels.Selector, force bool) error {
+	var remaining []api.Pod
+	err := wait.Poll(pollInterval, terminationTimeout, func() (bool, error) {
+		pods, err := k.GetPods(s)
+		if err != nil {
+			return false, err
+		}
+
+		remaining = pods
+		return len(remaining) == 0, nil
+	})
+
+	if err != wait.ErrWaitTimeout {
 		return err
 	}
+	if !force {
+		return fmt.Errorf("timed out waiting for %v pod(s) to terminate", len(remaining))
+	}
+
+	for _, p := range remaining {
+		if err := k.client.Pods(namespace).Delete(p.ObjectMeta.Name); err != nil {
+			return err
+		}
+	}
 
-	// Profit?
 	return nil
 }
 
+func (k KubernetesExecutor) WatchReplicationControllers(s labels.Selector, resourceVersion string) (watch.Interface, error) {
+	return k.client.ReplicationControllers(namespace).Watch(s, labels.Everything(), resourceVersion)
+}
+
+// The vendored client can't watch pods, though the apiserver can.
+func (k KubernetesExecutor) WatchPods(s labels.Selector, resourceVersion string) (watch.Interface, error) {
+	return k.client.Get().
+		Prefix("watch").
+		Namespace(namespace).
+		Resource("pods").
+		Param("resourceVersion", resourceVersion).
+		SelectorParam("labels", s).
+		SelectorParam("fields", labels.Everything()).
+		Watch()
+}
+
+func (k KubernetesExecutor) UpdatePod(p api.Pod) error {
+	_, err := k.client.Pods(namespace).Update(&p)
+	return err
+}
+
+func (k KubernetesExecutor) DeletePod(name string) error {
+	return k.client.Pods(namespace).Delete(name)
+}
+
 func (k KubernetesExecutor) CreateKServices(ks []api.Service) error {
 	for _, s := range ks {
 		_, err := k.client.Services(namespace).Create(&s)
@@ -114,6 +353,69 @@ func (k KubernetesExecutor) CreateKServices(ks []api.Service) error {
 	return nil
 }
 
+func (k KubernetesExecutor) GetKServices(s labels.Selector) ([]api.Service, error) {
+	sl, err := k.client.Services(namespace).List(s)
+	if err != nil {
+		return []api.Service{}, err
+	}
+
+	return sl.Items, nil
+}
+
+func (k KubernetesExecutor) UpdateKService(ks api.Service) error {
+	_, err := k.client.Services(namespace).Update(&ks)
+	return err
+}
+
+func (k KubernetesExecutor) DeleteKService(name string) error {
+	return k.client.Services(namespace).Delete(name)
+}
+
+func (k KubernetesExecutor) CreateSecret(s api.Secret) error {
+	_, err := k.client.Secrets(namespace).Create(&s)
+	return err
+}
+
+func (k KubernetesExecutor) GetSecrets(s labels.Selector) ([]api.Secret, error) {
+	sl, err := k.client.Secrets(namespace).List(s, labels.Everything())
+	if err != nil {
+		return []api.Secret{}, err
+	}
+
+	return sl.Items, nil
+}
+
+func (k KubernetesExecutor) DeleteSecret(name string) error {
+	return k.client.Secrets(namespace).Delete(name)
+}
+
+func (k KubernetesExecutor) GetNodes() ([]api.Node, error) {
+	nl, err := k.client.Nodes().List()
+	if err != nil {
+		return []api.Node{}, err
+	}
+
+	return nl.Items, nil
+}
+
+func (k KubernetesExecutor) GetResourceQuotas() ([]api.ResourceQuota, error) {
+	ql, err := k.client.ResourceQuotas(namespace).List(labels.Everything())
+	if err != nil {
+		return []api.ResourceQuota{}, err
+	}
+
+	return ql.Items, nil
+}
+
+func (k KubernetesExecutor) GetLimitRanges() ([]api.LimitRange, error) {
+	ll, err := k.client.LimitRanges(namespace).List(labels.Everything())
+	if err != nil {
+		return []api.LimitRange{}, err
+	}
+
+	return ll.Items, nil
+}
+
 func (k KubernetesExecutor) IsHealthy() bool {
 	if _, err := k.client.Nodes().List(); err != nil {
 		return false
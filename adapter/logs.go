@@ -0,0 +1,161 @@
+package adapter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// LogOptions narrow down which logs StreamServiceLogs writes. A Tail of zero
+// means all lines, and an empty Pod means every pod of the service. Anything
+// received from Stop ends a followed stream early.
+type LogOptions struct {
+	Tail   int
+	Pod    string
+	Follow bool
+	Stop   <-chan bool
+}
+
+// StreamServiceLogs writes the container logs of a service's pods to w. With
+// more than one pod each line is prefixed with the name of the pod it came
+// from. Followed streams run until every pod's stream ends or Stop fires.
+func (a KubernetesAdapter) StreamServiceLogs(id string, opts LogOptions, w io.Writer) error {
+	if opts.Tail < 0 {
+		return pmxadapter.NewError(http.StatusBadRequest, "tail can't be negative")
+	}
+
+	pods, err := servicePods(id, opts.Pod)
+	if err != nil {
+		return err
+	}
+
+	tail := "all"
+	if opts.Tail > 0 {
+		tail = strconv.Itoa(opts.Tail)
+	}
+
+	streams := make([]io.ReadCloser, 0, len(pods))
+	prefixes := make([]string, 0, len(pods))
+	for _, p := range pods {
+		// A pod that hasn't been scheduled has nowhere to read logs from.
+		if p.Status.Host == "" {
+			continue
+		}
+
+		r, err := DefaultExecutor.GetContainerLogs(p.Status.Host, p.ObjectMeta.Name, id, tail, opts.Follow)
+		if err != nil {
+			closeAll(streams)
+			return err
+		}
+		streams = append(streams, r)
+		if len(pods) > 1 {
+			prefixes = append(prefixes, fmt.Sprintf("[%v] ", p.ObjectMeta.Name))
+		} else {
+			prefixes = append(prefixes, "")
+		}
+	}
+	defer closeAll(streams)
+
+	lw := &lineWriter{w: w}
+	if !opts.Follow {
+		for i, r := range streams {
+			if err := lw.copyLines(r, prefixes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Following never ends on its own, so pods are streamed side by side.
+	done := make(chan error, len(streams))
+	for i, r := range streams {
+		go func(r io.Reader, prefix string) {
+			done <- lw.copyLines(r, prefix)
+		}(r, prefixes[i])
+	}
+
+	var copyErr error
+	pending := len(streams)
+wait:
+	for pending > 0 {
+		select {
+		case copyErr = <-done:
+			pending--
+			if copyErr != nil {
+				break wait
+			}
+		case <-opts.Stop:
+			break wait
+		}
+	}
+
+	// Nothing may be written to w once this returns, so streams still being
+	// copied are closed and their copies waited out.
+	closeAll(streams)
+	for ; pending > 0; pending-- {
+		<-done
+	}
+	return copyErr
+}
+
+func servicePods(id string, podName string) ([]api.Pod, error) {
+	selector := labels.OneTermEqualSelector("service-name", id)
+	pods, err := DefaultExecutor.GetPods(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if podName != "" {
+		for _, p := range pods {
+			if p.ObjectMeta.Name == podName {
+				return []api.Pod{p}, nil
+			}
+		}
+		return nil, pmxadapter.NewNotFoundError(fmt.Sprintf("pod '%v' not found for service '%v'", podName, id))
+	}
+
+	if len(pods) == 0 {
+		return nil, pmxadapter.NewNotFoundError(fmt.Sprintf("no pods found for service '%v'", id))
+	}
+	return pods, nil
+}
+
+func closeAll(streams []io.ReadCloser) {
+	for _, r := range streams {
+		r.Close()
+	}
+}
+
+// A lineWriter keeps lines from concurrent streams whole.
+type lineWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (lw *lineWriter) copyLines(r io.Reader, prefix string) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			lw.Lock()
+			_, wErr := io.WriteString(lw.w, prefix+line)
+			lw.Unlock()
+			if wErr != nil {
+				return wErr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
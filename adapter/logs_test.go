@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func logsPod(name string, host string) api.Pod {
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Status:     api.PodStatus{Host: host, Phase: api.PodRunning},
+	}
+}
+
+func setupLogs() {
+	setupRCs()
+	te.Pods = []api.Pod{logsPod("pod-a", "host-1"), logsPod("pod-b", "host-2")}
+	te.Logs = map[string]string{
+		"pod-a": "a one\na two\n",
+		"pod-b": "b one",
+	}
+}
+
+func TestSuccessfulStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	var b bytes.Buffer
+	err := adapter.StreamServiceLogs("test-service", LogOptions{Tail: 10}, &b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "[pod-a] a one\n[pod-a] a two\n[pod-b] b one", b.String())
+	assert.Equal(t, "service-name=test-service", te.GotPodsSelector.String())
+	assert.Equal(t, []string{
+		"host-1 pod-a test-service 10 false",
+		"host-2 pod-b test-service 10 false",
+	}, te.GotLogsRequests)
+}
+
+func TestSuccessfulSinglePodStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	var b bytes.Buffer
+	err := adapter.StreamServiceLogs("test-service", LogOptions{Pod: "pod-a"}, &b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a one\na two\n", b.String())
+	assert.Equal(t, []string{"host-1 pod-a test-service all false"}, te.GotLogsRequests)
+}
+
+func TestSuccessfulFollowedStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	var b bytes.Buffer
+	err := adapter.StreamServiceLogs("test-service", LogOptions{Follow: true}, &b)
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "[pod-a] a one\n")
+	assert.Contains(t, b.String(), "[pod-b] b one")
+	assert.Contains(t, te.GotLogsRequests[0], "true")
+}
+
+func TestSuccessfulUnscheduledStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	te.Pods = []api.Pod{logsPod("pod-a", "")}
+	var b bytes.Buffer
+	err := adapter.StreamServiceLogs("test-service", LogOptions{}, &b)
+
+	assert.NoError(t, err)
+	assert.Empty(t, b.String())
+	assert.Empty(t, te.GotLogsRequests)
+}
+
+func TestErroredUnknownPodStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	err := adapter.StreamServiceLogs("test-service", LogOptions{Pod: "pod-z"}, &bytes.Buffer{})
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+		assert.Equal(t, "pod 'pod-z' not found for service 'test-service'", pmxErr.Message)
+	}
+}
+
+func TestErroredNoPodsStreamServiceLogs(t *testing.T) {
+	setupRCs()
+	err := adapter.StreamServiceLogs("test-service", LogOptions{}, &bytes.Buffer{})
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+	}
+}
+
+func TestErroredStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	te.GetLogsError = errors.New("test error")
+	err := adapter.StreamServiceLogs("test-service", LogOptions{}, &bytes.Buffer{})
+
+	assert.EqualError(t, err, "test error")
+}
+
+func TestSuccessfulStoppedStreamServiceLogs(t *testing.T) {
+	setupLogs()
+	r, w := io.Pipe()
+	te.LogStreams = map[string]io.ReadCloser{"pod-a": r}
+	stop := make(chan bool)
+	go func() {
+		io.WriteString(w, "a one\n")
+		close(stop)
+	}()
+	var out bytes.Buffer
+	err := adapter.StreamServiceLogs("test-service", LogOptions{Follow: true, Stop: stop}, &out)
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "[pod-a] a one\n")
+	_, err = io.WriteString(w, "a two\n")
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+func TestErroredKubeletGetContainerLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "pod not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+	e, err := NewKubernetesExecutor(server.URL, "", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = e.GetContainerLogs("host-1", "pod-a", "test-service", "all", false)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "404 Not Found: pod not found")
+	}
+}
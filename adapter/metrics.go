@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/google/cadvisor/info"
+)
+
+// ResourceUsage is a snapshot of what a container is consuming. CPU is a rate,
+// in cores, over the most recent sampling interval; network counters are
+// cumulative since the container started.
+type ResourceUsage struct {
+	CPUCores       float64 `json:"cpuCores"`
+	MemoryBytes    uint64  `json:"memoryBytes"`
+	NetworkRxBytes uint64  `json:"networkRxBytes"`
+	NetworkTxBytes uint64  `json:"networkTxBytes"`
+}
+
+// PodMetrics is one replica's share of a service's usage. A pod whose kubelet
+// couldn't be reached has an Error instead, and isn't counted in the total.
+type PodMetrics struct {
+	ResourceUsage
+	Pod   string `json:"pod"`
+	Host  string `json:"host"`
+	Error string `json:"error,omitempty"`
+}
+
+// ServiceMetrics is a service's usage totalled across its running replicas,
+// along with each replica's part in it.
+type ServiceMetrics struct {
+	ID    string        `json:"id"`
+	Total ResourceUsage `json:"total"`
+	Pods  []PodMetrics  `json:"pods"`
+}
+
+func (a KubernetesAdapter) GetServiceMetrics(id string) (ServiceMetrics, error) {
+	pods, err := servicePods(id, "")
+	if err != nil {
+		return ServiceMetrics{}, err
+	}
+
+	sm := ServiceMetrics{ID: id, Pods: make([]PodMetrics, 0, len(pods))}
+	for _, p := range pods {
+		if p.Status.Phase != api.PodRunning || p.Status.Host == "" {
+			continue
+		}
+
+		pm := PodMetrics{Pod: p.ObjectMeta.Name, Host: p.Status.Host}
+		ci, err := DefaultExecutor.GetContainerStats(p.Status.Host, p.ObjectMeta.Name, id)
+		if err != nil {
+			pm.Error = err.Error()
+			sm.Pods = append(sm.Pods, pm)
+			continue
+		}
+
+		pm.ResourceUsage = usageFromStats(ci.Stats)
+		sm.Pods = append(sm.Pods, pm)
+		sm.Total.CPUCores += pm.CPUCores
+		sm.Total.MemoryBytes += pm.MemoryBytes
+		sm.Total.NetworkRxBytes += pm.NetworkRxBytes
+		sm.Total.NetworkTxBytes += pm.NetworkTxBytes
+	}
+
+	return sm, nil
+}
+
+// Stats come oldest first. CPU usage is only reported cumulatively, so a rate
+// needs at least two samples.
+func usageFromStats(stats []*info.ContainerStats) ResourceUsage {
+	if len(stats) == 0 {
+		return ResourceUsage{}
+	}
+
+	latest := stats[len(stats)-1]
+	u := ResourceUsage{
+		MemoryBytes:    latest.Memory.Usage,
+		NetworkRxBytes: latest.Network.RxBytes,
+		NetworkTxBytes: latest.Network.TxBytes,
+	}
+
+	if len(stats) > 1 {
+		previous := stats[len(stats)-2]
+		elapsed := latest.Timestamp.Sub(previous.Timestamp)
+		if elapsed > 0 && latest.Cpu.Usage.Total >= previous.Cpu.Usage.Total {
+			used := latest.Cpu.Usage.Total - previous.Cpu.Usage.Total
+			u.CPUCores = float64(used) / float64(elapsed.Nanoseconds())
+		}
+	}
+
+	return u
+}
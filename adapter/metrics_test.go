@@ -0,0 +1,83 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/google/cadvisor/info"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStats(at time.Time, cpu uint64, memory uint64, rx uint64) *info.ContainerStats {
+	s := &info.ContainerStats{Timestamp: at}
+	s.Cpu.Usage.Total = cpu
+	s.Memory.Usage = memory
+	s.Network.RxBytes = rx
+	s.Network.TxBytes = rx / 2
+	return s
+}
+
+func setupMetrics() {
+	setupRCs()
+	pending := logsPod("pod-c", "host-3")
+	pending.Status.Phase = api.PodPending
+	te.Pods = []api.Pod{logsPod("pod-a", "host-1"), logsPod("pod-b", "host-2"), pending}
+
+	start := time.Now()
+	second := start.Add(time.Second)
+	te.Stats = map[string][]*info.ContainerStats{
+		"pod-a": {testStats(start, 0, 100, 10), testStats(second, 500000000, 200, 20)},
+		"pod-b": {testStats(start, 0, 50, 4), testStats(second, 250000000, 60, 6)},
+	}
+}
+
+func TestSuccessfulGetServiceMetrics(t *testing.T) {
+	setupMetrics()
+	sm, err := adapter.GetServiceMetrics("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", sm.ID)
+	assert.InDelta(t, 0.75, sm.Total.CPUCores, 0.0001)
+	assert.Equal(t, uint64(260), sm.Total.MemoryBytes)
+	assert.Equal(t, uint64(26), sm.Total.NetworkRxBytes)
+	assert.Equal(t, uint64(13), sm.Total.NetworkTxBytes)
+	if assert.Len(t, sm.Pods, 2) {
+		assert.Equal(t, "pod-a", sm.Pods[0].Pod)
+		assert.Equal(t, "host-1", sm.Pods[0].Host)
+		assert.InDelta(t, 0.5, sm.Pods[0].CPUCores, 0.0001)
+		assert.Equal(t, uint64(200), sm.Pods[0].MemoryBytes)
+	}
+}
+
+func TestSuccessfulUnreachableGetServiceMetrics(t *testing.T) {
+	setupMetrics()
+	delete(te.Stats, "pod-b")
+	sm, err := adapter.GetServiceMetrics("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), sm.Total.MemoryBytes)
+	if assert.Len(t, sm.Pods, 2) {
+		assert.Equal(t, "no stats for pod-b on host-2", sm.Pods[1].Error)
+	}
+}
+
+func TestErroredNoPodsGetServiceMetrics(t *testing.T) {
+	setupRCs()
+	_, err := adapter.GetServiceMetrics("test-service")
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+	}
+}
+
+func TestSingleSampleUsageFromStats(t *testing.T) {
+	u := usageFromStats([]*info.ContainerStats{testStats(time.Now(), 900, 10, 0)})
+
+	assert.Equal(t, 0.0, u.CPUCores)
+	assert.Equal(t, uint64(10), u.MemoryBytes)
+	assert.Equal(t, ResourceUsage{}, usageFromStats(nil))
+}
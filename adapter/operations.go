@@ -0,0 +1,291 @@
+package adapter
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+const (
+	CreateOperation   = "create"
+	RollbackOperation = "rollback"
+
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+
+	// Services of an operation are waiting until their ReplicationController
+	// is created, or rolled back once their pods are all replaced, or until
+	// the operation fails before getting to them.
+	ServiceWaiting    = "waiting"
+	ServiceCreated    = "created"
+	ServiceRolledBack = "rolledBack"
+	ServiceFailed     = "failed"
+)
+
+// Finished operations are kept for this long, so that a caller that lost
+// track of one can still find out how it went.
+var operationRetention = time.Hour
+
+var newOperationID = newApplicationID
+
+// An Operation is a create or a rollback running in the background. Result is
+// only set once it has succeeded, and Error and Code once it has failed; Code
+// is the HTTP status the same failure would have had from a synchronous call.
+type Operation struct {
+	ID         string                         `json:"id"`
+	Kind       string                         `json:"kind"`
+	State      string                         `json:"state"`
+	StartedAt  time.Time                      `json:"startedAt"`
+	FinishedAt *time.Time                     `json:"finishedAt,omitempty"`
+	Services   []OperationService             `json:"services"`
+	Result     []pmxadapter.ServiceDeployment `json:"result,omitempty"`
+	Error      string                         `json:"error,omitempty"`
+	Code       int                            `json:"code,omitempty"`
+}
+
+// OperationService is the progress of one service in an Operation.
+type OperationService struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	ActualState string `json:"actualState,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// A progressFunc is told about each service of a create as its
+// ReplicationController is created, or fails to be.
+type progressFunc func(i int, sd pmxadapter.ServiceDeployment, err error)
+
+// operations is shared by every request, and by the creates running in the
+// background, so Operations only ever leave it as copies.
+var operations = &operationStore{byID: map[string]*Operation{}}
+
+type operationStore struct {
+	sync.RWMutex
+	byID map[string]*Operation
+}
+
+// StartCreateServices runs CreateServicesWithOptions in the background,
+// returning an Operation to follow it by. Only the options are checked before
+// it's started.
+func (a KubernetesAdapter) StartCreateServices(services []*pmxadapter.Service, opts CreateOptions) (Operation, error) {
+	if _, err := checkCreateOptions(opts); err != nil {
+		return Operation{}, err
+	}
+	if err := checkServiceNames(services); err != nil {
+		return Operation{}, err
+	}
+
+	op := &Operation{
+		ID:        newOperationID(),
+		Kind:      CreateOperation,
+		State:     OperationRunning,
+		StartedAt: time.Now().UTC(),
+		Services:  make([]OperationService, len(services)),
+	}
+	for i, s := range services {
+		op.Services[i] = OperationService{
+			Name:  s.Name,
+			ID:    sanitizeServiceName(s.Name),
+			State: ServiceWaiting,
+		}
+	}
+
+	operations.add(op)
+	snapshot := operations.get(op.ID)
+	go operations.run(a, op.ID, services, opts)
+
+	return *snapshot, nil
+}
+
+// GetOperations returns every operation still being kept, oldest first.
+func (a KubernetesAdapter) GetOperations() []Operation {
+	return operations.list()
+}
+
+func (a KubernetesAdapter) GetOperation(id string) (Operation, error) {
+	op := operations.get(id)
+	if op == nil {
+		return Operation{}, pmxadapter.NewNotFoundError(fmt.Sprintf("operation '%v' not found", id))
+	}
+
+	return *op, nil
+}
+
+// StartRollbackService puts a service back on an earlier revision like
+// RollbackService, but only waits for its ReplicationController to be
+// updated. Its pods are replaced in the background, followed by the returned
+// Operation.
+func (a KubernetesAdapter) StartRollbackService(id string, revision int) (Operation, error) {
+	rc, err := rollBackReplicationController(id, revision)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	op := &Operation{
+		ID:        newOperationID(),
+		Kind:      RollbackOperation,
+		State:     OperationRunning,
+		StartedAt: time.Now().UTC(),
+		Services:  []OperationService{{Name: id, ID: id, State: ServiceWaiting}},
+	}
+
+	operations.add(op)
+	snapshot := operations.get(op.ID)
+	go operations.runRollout(op.ID, rc)
+
+	return *snapshot, nil
+}
+
+func (s *operationStore) run(a KubernetesAdapter, id string, services []*pmxadapter.Service, opts CreateOptions) {
+	defer s.recoverFailure(id)
+
+	opts.progress = func(i int, sd pmxadapter.ServiceDeployment, err error) {
+		s.progress(id, i, sd, err)
+	}
+	sds, err := a.CreateServicesWithOptions(services, opts)
+	s.finish(id, sds, err)
+}
+
+func (s *operationStore) runRollout(id string, rc api.ReplicationController) {
+	defer s.recoverFailure(id)
+
+	sd, err := rollOut(rc)
+	s.progress(id, 0, sd, err)
+	if err != nil {
+		s.finish(id, nil, err)
+		return
+	}
+	s.finish(id, []pmxadapter.ServiceDeployment{sd}, nil)
+}
+
+// Nothing is waiting on a background operation to hear about a panic, so it's
+// recorded like any other failure.
+func (s *operationStore) recoverFailure(id string) {
+	if r := recover(); r != nil {
+		log.Printf("Operation %v panicked: %v", id, r)
+		s.finish(id, nil, fmt.Errorf("unexpected failure: %v", r))
+	}
+}
+
+func (s *operationStore) add(op *Operation) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expire()
+	s.byID[op.ID] = op
+}
+
+func (s *operationStore) progress(id string, i int, sd pmxadapter.ServiceDeployment, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	op, exists := s.byID[id]
+	if !exists || i >= len(op.Services) {
+		return
+	}
+
+	if err != nil {
+		op.Services[i].State = ServiceFailed
+		op.Services[i].Error = errorMessage(err)
+		return
+	}
+	op.Services[i].State = ServiceCreated
+	if op.Kind == RollbackOperation {
+		op.Services[i].State = ServiceRolledBack
+	}
+	op.Services[i].ActualState = sd.ActualState
+}
+
+func (s *operationStore) finish(id string, sds []pmxadapter.ServiceDeployment, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	op, exists := s.byID[id]
+	if !exists || op.State != OperationRunning {
+		return
+	}
+
+	now := time.Now().UTC()
+	op.FinishedAt = &now
+	if err != nil {
+		op.State = OperationFailed
+		op.Error = errorMessage(err)
+		op.Code, _ = errorResponse(err)
+		return
+	}
+	op.State = OperationSucceeded
+	op.Result = sds
+}
+
+func (s *operationStore) get(id string) *Operation {
+	s.RLock()
+	defer s.RUnlock()
+
+	op, exists := s.byID[id]
+	if !exists {
+		return nil
+	}
+	c := op.copy()
+	return &c
+}
+
+func (s *operationStore) list() []Operation {
+	s.RLock()
+	defer s.RUnlock()
+
+	ops := make([]Operation, 0, len(s.byID))
+	for _, op := range s.byID {
+		ops = append(ops, op.copy())
+	}
+	sort.Sort(byStartedAt(ops))
+	return ops
+}
+
+// Called with the lock held.
+func (s *operationStore) expire() {
+	for id, op := range s.byID {
+		if op.FinishedAt != nil && time.Since(*op.FinishedAt) > operationRetention {
+			delete(s.byID, id)
+		}
+	}
+}
+
+// Operations are updated in place as they run, so what's handed out mustn't
+// share anything with them.
+func (op Operation) copy() Operation {
+	services := make([]OperationService, len(op.Services))
+	copy(services, op.Services)
+	op.Services = services
+
+	if op.Result != nil {
+		result := make([]pmxadapter.ServiceDeployment, len(op.Result))
+		copy(result, op.Result)
+		op.Result = result
+	}
+	if op.FinishedAt != nil {
+		finishedAt := *op.FinishedAt
+		op.FinishedAt = &finishedAt
+	}
+	return op
+}
+
+// A pmxadapter.Error's own message, without the code it carries.
+func errorMessage(err error) string {
+	if pmxErr, ok := err.(*pmxadapter.Error); ok {
+		return pmxErr.Message
+	}
+	return err.Error()
+}
+
+type byStartedAt []Operation
+
+func (s byStartedAt) Len() int           { return len(s) }
+func (s byStartedAt) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStartedAt) Less(i, j int) bool { return s[i].StartedAt.Before(s[j].StartedAt) }
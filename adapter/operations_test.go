@@ -0,0 +1,137 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupOperations() {
+	servicesSetup()
+	newOperationID = func() string { return "test-operation" }
+}
+
+// Background creates against the TestExecutor take no time, but they're
+// still in another goroutine.
+func waitForOperation(t *testing.T, id string) Operation {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		op, err := adapter.GetOperation(id)
+		assert.NoError(t, err)
+		if op.State != OperationRunning {
+			return op
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("operation %v never finished", id)
+	return Operation{}
+}
+
+func startCreate(t *testing.T) Operation {
+	op, err := adapter.StartCreateServices(services, CreateOptions{})
+	assert.NoError(t, err)
+	return op
+}
+
+func TestSuccessfulStartCreateServices(t *testing.T) {
+	setupOperations()
+	op := startCreate(t)
+
+	assert.Equal(t, "test-operation", op.ID)
+	assert.Equal(t, OperationRunning, op.State)
+	if assert.Len(t, op.Services, 1) {
+		assert.Equal(t, "Test Service", op.Services[0].Name)
+		assert.Equal(t, "test-service", op.Services[0].ID)
+		assert.Equal(t, ServiceWaiting, op.Services[0].State)
+	}
+
+	op = waitForOperation(t, op.ID)
+	assert.Equal(t, OperationSucceeded, op.State)
+	assert.NotNil(t, op.FinishedAt)
+	assert.Equal(t, ServiceCreated, op.Services[0].State)
+	assert.Equal(t, "pending", op.Services[0].ActualState)
+	assert.Equal(t, []pmxadapter.ServiceDeployment{{ID: "test-service", ActualState: "pending"}}, op.Result)
+	assert.Equal(t, "test-service", te.CreatedSpec.ObjectMeta.Name)
+}
+
+func TestErroredRCCreationStartCreateServices(t *testing.T) {
+	setupOperations()
+	te.CreateRCError = errors.New("test error")
+	op := waitForOperation(t, startCreate(t).ID)
+
+	assert.Equal(t, OperationFailed, op.State)
+	assert.Equal(t, "test error", op.Error)
+	assert.Equal(t, http.StatusInternalServerError, op.Code)
+	assert.Equal(t, ServiceFailed, op.Services[0].State)
+	assert.Equal(t, "test error", op.Services[0].Error)
+	assert.Empty(t, op.Result)
+}
+
+func TestErroredValidationStartCreateServices(t *testing.T) {
+	setupOperations()
+	policy.MaxReplicasPerService = 1
+	services[0].Deployment.Count = 2
+	op := waitForOperation(t, startCreate(t).ID)
+
+	assert.Equal(t, OperationFailed, op.State)
+	assert.Equal(t, http.StatusForbidden, op.Code)
+	assert.Contains(t, op.Error, "deployment violates policy")
+	assert.Equal(t, ServiceWaiting, op.Services[0].State)
+}
+
+func TestGetOperationsCopies(t *testing.T) {
+	setupOperations()
+	waitForOperation(t, startCreate(t).ID)
+	ops := adapter.GetOperations()
+	if assert.Len(t, ops, 1) {
+		ops[0].Services[0].State = "changed"
+	}
+
+	op, _ := adapter.GetOperation("test-operation")
+	assert.Equal(t, ServiceCreated, op.Services[0].State)
+}
+
+func TestExpiredOperations(t *testing.T) {
+	setupOperations()
+	finished := time.Now().Add(-2 * operationRetention)
+	operations.add(&Operation{ID: "old", State: OperationSucceeded, FinishedAt: &finished})
+	operations.add(&Operation{ID: "running", State: OperationRunning})
+	waitForOperation(t, startCreate(t).ID)
+
+	ops := adapter.GetOperations()
+	assert.Len(t, ops, 2)
+	_, err := adapter.GetOperation("old")
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*pmxadapter.Error).Code)
+	}
+}
+
+func TestAsyncCreateServicesHandler(t *testing.T) {
+	setupOperations()
+	body := `[{"name": "Test Service", "source": "redis", "ports": [{"hostPort": 31981, "containerPort": 12345, "protocol": "TCP"}]}]`
+	r, _ := http.NewRequest("POST", "http://localhost/v1/services?async=true", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	code, response := createServices(adapter, r, w)
+
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "/v1/operations/test-operation", w.Header().Get("Location"))
+	assert.Contains(t, response, `"state":"running"`)
+	waitForOperation(t, "test-operation")
+}
+
+func TestSyncCreateServicesHandler(t *testing.T) {
+	setupOperations()
+	body := `[{"name": "Test Service", "source": "redis"}]`
+	r, _ := http.NewRequest("POST", "http://localhost/v1/services", strings.NewReader(body))
+	code, response := createServices(adapter, r, httptest.NewRecorder())
+
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Contains(t, response, `"id":"test-service"`)
+}
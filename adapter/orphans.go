@@ -0,0 +1,147 @@
+package adapter
+
+import (
+	"log"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// Objects younger than this may belong to a create that's still in progress,
+// so they're never considered orphans.
+const orphanGracePeriod = time.Minute
+
+// An OrphanReport lists the Panamax-created objects whose counterparts are
+// missing: KServices and Secrets labeled for a service that has no
+// ReplicationController, and ReplicationControllers exposing ports that no
+// KService routes to. These are left behind by partially failed creates and
+// destroys.
+type OrphanReport struct {
+	KServices              []string `json:"services"`
+	ReplicationControllers []string `json:"replicationControllers"`
+	Secrets                []string `json:"secrets"`
+	Deleted                bool     `json:"deleted"`
+}
+
+// FindOrphans reports orphaned objects without touching them.
+func (a KubernetesAdapter) FindOrphans() (OrphanReport, error) {
+	return collectOrphans(false)
+}
+
+// CollectOrphans reports orphaned objects and deletes them.
+func (a KubernetesAdapter) CollectOrphans() (OrphanReport, error) {
+	return collectOrphans(true)
+}
+
+// CollectOrphansEvery runs CollectOrphans on an interval, forever. Failures
+// are logged and retried next time around.
+func (a KubernetesAdapter) CollectOrphansEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		report, err := a.CollectOrphans()
+		if err != nil {
+			log.Printf("Orphan collection failed: %v", err)
+			continue
+		}
+		if len(report.KServices) > 0 || len(report.ReplicationControllers) > 0 || len(report.Secrets) > 0 {
+			log.Printf("Collected orphaned services %v, replication controllers %v and secrets %v", report.KServices, report.ReplicationControllers, report.Secrets)
+		}
+	}
+}
+
+func collectOrphans(delete bool) (OrphanReport, error) {
+	rcs, err := DefaultExecutor.GetReplicationControllers()
+	if err != nil {
+		return OrphanReport{}, err
+	}
+	kServices, err := DefaultExecutor.GetKServices(labels.Everything())
+	if err != nil {
+		return OrphanReport{}, err
+	}
+	secrets, err := DefaultExecutor.GetSecrets(labels.Everything())
+	if err != nil {
+		return OrphanReport{}, err
+	}
+
+	report := OrphanReport{
+		KServices:              make([]string, 0),
+		ReplicationControllers: make([]string, 0),
+		Secrets:                make([]string, 0),
+	}
+
+	rcNames := map[string]bool{}
+	for _, rc := range rcs {
+		if isPanamaxReplicationController(rc) {
+			rcNames[rc.ObjectMeta.Name] = true
+		}
+	}
+
+	routed := map[string]bool{}
+	for _, ks := range kServices {
+		if ks.Spec.Selector["panamax"] != "panamax" {
+			continue
+		}
+
+		serviceName := ks.ObjectMeta.Labels["service-name"]
+		routed[serviceName] = true
+		if !rcNames[serviceName] && isPastGracePeriod(ks.ObjectMeta) {
+			report.KServices = append(report.KServices, ks.ObjectMeta.Name)
+		}
+	}
+
+	// Only the adapter's own Secrets are labeled with an application.
+	for _, secret := range secrets {
+		l := secret.ObjectMeta.Labels
+		if l[applicationLabel] != "" && l["service-name"] != "" && !rcNames[l["service-name"]] && isPastGracePeriod(secret.ObjectMeta) {
+			report.Secrets = append(report.Secrets, secret.ObjectMeta.Name)
+		}
+	}
+
+	for _, rc := range rcs {
+		if isPanamaxReplicationController(rc) && !isNextTrack(rc) && exposesPorts(rc) && !routed[rc.ObjectMeta.Name] && isPastGracePeriod(rc.ObjectMeta) {
+			report.ReplicationControllers = append(report.ReplicationControllers, rc.ObjectMeta.Name)
+		}
+	}
+
+	if !delete {
+		return report, nil
+	}
+
+	for _, name := range report.KServices {
+		if err := DefaultExecutor.DeleteKService(name); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range report.Secrets {
+		if err := DefaultExecutor.DeleteSecret(name); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range report.ReplicationControllers {
+		if err := DefaultExecutor.DeleteReplicationController(name, false); err != nil {
+			return report, err
+		}
+	}
+	report.Deleted = true
+
+	return report, nil
+}
+
+func isPastGracePeriod(m api.ObjectMeta) bool {
+	return time.Since(m.CreationTimestamp.Time) > orphanGracePeriod
+}
+
+func isPanamaxReplicationController(rc api.ReplicationController) bool {
+	t := rc.Spec.Template
+	return t != nil && t.ObjectMeta.Labels["panamax"] == "panamax"
+}
+
+func exposesPorts(rc api.ReplicationController) bool {
+	for _, c := range rc.Spec.Template.Spec.Containers {
+		if len(c.Ports) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
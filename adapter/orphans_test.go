@@ -0,0 +1,117 @@
+package adapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func panamaxRC(name string, ports []api.Port) api.ReplicationController {
+	return api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Spec: api.ReplicationControllerSpec{
+			Template: &api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"panamax": "panamax"}},
+				Spec:       api.PodSpec{Containers: []api.Container{{Name: name, Ports: ports}}},
+			},
+		},
+	}
+}
+
+func panamaxKService(name string, serviceName string) api.Service {
+	return api.Service{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"service-name": serviceName},
+		},
+		Spec: api.ServiceSpec{Selector: map[string]string{"panamax": "panamax"}},
+	}
+}
+
+func setupOrphans() {
+	adapterSetup()
+	ports := []api.Port{{ContainerPort: 80}}
+	te.RCs = []api.ReplicationController{
+		panamaxRC("web", ports),
+		panamaxRC("worker", nil),
+		panamaxRC("lonely", ports),
+		{ObjectMeta: api.ObjectMeta{Name: "not-ours"}},
+	}
+	te.KServices = []api.Service{
+		panamaxKService("web", "web"),
+		panamaxKService("web-alias", "web"),
+		panamaxKService("gone", "gone"),
+		panamaxKService("gone-alias", "gone"),
+		{ObjectMeta: api.ObjectMeta{Name: "kubernetes"}},
+	}
+	te.Secrets = []api.Secret{
+		panamaxSecret("web"),
+		panamaxSecret("gone"),
+		{ObjectMeta: api.ObjectMeta{Name: "not-ours", Labels: map[string]string{"service-name": "gone"}}},
+	}
+}
+
+func panamaxSecret(serviceName string) api.Secret {
+	return api.Secret{
+		ObjectMeta: api.ObjectMeta{
+			Name:   secretName(serviceName),
+			Labels: map[string]string{"service-name": serviceName, applicationLabel: "test-app"},
+		},
+	}
+}
+
+func TestSuccessfulFindOrphans(t *testing.T) {
+	setupOrphans()
+	report, err := adapter.FindOrphans()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gone", "gone-alias"}, report.KServices)
+	assert.Equal(t, []string{"lonely"}, report.ReplicationControllers)
+	assert.Equal(t, []string{"gone-secrets"}, report.Secrets)
+	assert.False(t, report.Deleted)
+	assert.Empty(t, te.DeletedKServices)
+	assert.Empty(t, te.DestroyedServiceIDs)
+}
+
+func TestSuccessfulCollectOrphans(t *testing.T) {
+	setupOrphans()
+	report, err := adapter.CollectOrphans()
+
+	assert.NoError(t, err)
+	assert.True(t, report.Deleted)
+	assert.Equal(t, []string{"gone", "gone-alias"}, te.DeletedKServices)
+	assert.Equal(t, []string{"gone-secrets"}, te.DeletedSecrets)
+	assert.Equal(t, []string{"lonely"}, te.DestroyedServiceIDs)
+}
+
+func TestSuccessfulNothingOrphaned(t *testing.T) {
+	adapterSetup()
+	report, err := adapter.FindOrphans()
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.KServices)
+	assert.Empty(t, report.ReplicationControllers)
+	assert.Empty(t, report.Secrets)
+}
+
+func TestSuccessfulRecentlyCreatedFindOrphans(t *testing.T) {
+	setupOrphans()
+	te.KServices[2].ObjectMeta.CreationTimestamp = util.NewTime(time.Now())
+	report, err := adapter.FindOrphans()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gone-alias"}, report.KServices)
+}
+
+func TestErroredCollectOrphans(t *testing.T) {
+	setupOrphans()
+	te.DeletionError = errors.New("test error")
+	report, err := adapter.CollectOrphans()
+
+	assert.EqualError(t, err, "test error")
+	assert.False(t, report.Deleted)
+}
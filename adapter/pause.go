@@ -0,0 +1,194 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// A paused service is scaled to zero, with the replica count to come back to
+// kept in this annotation. The RC keeps everything else, so nothing about the
+// service is lost.
+const pausedAnnotation = "panamax.io/paused-replicas"
+
+// PauseService scales a service to zero, remembering how many replicas it
+// ran. Pausing a paused service leaves it as it is.
+func (a KubernetesAdapter) PauseService(id string) (pmxadapter.ServiceDeployment, error) {
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if err := checkPausable(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	if rc, err = pauseReplicationController(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	return serviceDeployment(rc)
+}
+
+// ResumeService scales a paused service back to the replicas it ran before.
+func (a KubernetesAdapter) ResumeService(id string) (pmxadapter.ServiceDeployment, error) {
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if !isPaused(rc) {
+		msg := fmt.Sprintf("service '%v' isn't paused", id)
+		return pmxadapter.ServiceDeployment{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+	// Other services may have scaled into the room a paused one left.
+	replicas, err := pausedReplicas(rc)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if err := checkServiceReplicas(replicas); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if err := checkApplicationReplicas(rc, replicas-rc.Spec.Replicas); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	if rc, err = resumeReplicationController(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	return serviceDeployment(rc)
+}
+
+// PauseApplication pauses every service of an application, dependents before
+// the services they link to. Nothing is paused if any of them can't be.
+func (a KubernetesAdapter) PauseApplication(id string) (ApplicationDeployment, error) {
+	rcs, err := applicationReplicationControllers(id)
+	if err != nil {
+		return ApplicationDeployment{}, err
+	}
+	for _, rc := range rcs {
+		if err := checkPausable(rc); err != nil {
+			return ApplicationDeployment{}, err
+		}
+	}
+
+	ordered, err := destructionOrder(rcs)
+	if err != nil {
+		return ApplicationDeployment{}, err
+	}
+	paused := make([]api.ReplicationController, len(ordered))
+	for i, rc := range ordered {
+		if paused[i], err = pauseReplicationController(rc); err != nil {
+			return ApplicationDeployment{}, err
+		}
+	}
+
+	return applicationFromReplicationControllers(id, paused)
+}
+
+// ResumeApplication resumes an application's paused services, in the reverse
+// of the order they were paused. Services that aren't paused are left alone.
+// Nothing is resumed if the policy doesn't leave room for all of them.
+func (a KubernetesAdapter) ResumeApplication(id string) (ApplicationDeployment, error) {
+	rcs, err := applicationReplicationControllers(id)
+	if err != nil {
+		return ApplicationDeployment{}, err
+	}
+	added := 0
+	for _, rc := range rcs {
+		if !isPaused(rc) {
+			continue
+		}
+		replicas, err := pausedReplicas(rc)
+		if err != nil {
+			return ApplicationDeployment{}, err
+		}
+		if err := checkServiceReplicas(replicas); err != nil {
+			return ApplicationDeployment{}, err
+		}
+		added += replicas - rc.Spec.Replicas
+	}
+	if len(rcs) > 0 {
+		if err := checkApplicationReplicas(rcs[0], added); err != nil {
+			return ApplicationDeployment{}, err
+		}
+	}
+
+	ordered, err := destructionOrder(rcs)
+	if err != nil {
+		return ApplicationDeployment{}, err
+	}
+	resumed := make([]api.ReplicationController, len(ordered))
+	for i := len(ordered) - 1; i >= 0; i-- {
+		rc := ordered[i]
+		if isPaused(rc) {
+			if rc, err = resumeReplicationController(rc); err != nil {
+				return ApplicationDeployment{}, err
+			}
+		}
+		resumed[i] = rc
+	}
+
+	return applicationFromReplicationControllers(id, resumed)
+}
+
+func isPaused(rc api.ReplicationController) bool {
+	_, paused := rc.ObjectMeta.Annotations[pausedAnnotation]
+	return paused
+}
+
+// An update's pods aren't the service's own, and pausing would leave them
+// running.
+func checkPausable(rc api.ReplicationController) error {
+	if _, updating := rc.ObjectMeta.Annotations[updateAnnotation]; updating {
+		msg := fmt.Sprintf("service '%v' is being updated, promote or abort the update first", rc.ObjectMeta.Name)
+		return pmxadapter.NewError(http.StatusConflict, msg)
+	}
+	return nil
+}
+
+// The count and the annotation change in the same update, so a service is
+// never scaled down without a record of what it ran.
+func pauseReplicationController(rc api.ReplicationController) (api.ReplicationController, error) {
+	if isPaused(rc) {
+		return rc, nil
+	}
+
+	if rc.ObjectMeta.Annotations == nil {
+		rc.ObjectMeta.Annotations = map[string]string{}
+	}
+	rc.ObjectMeta.Annotations[pausedAnnotation] = strconv.Itoa(rc.Spec.Replicas)
+	rc.Spec.Replicas = 0
+	return DefaultExecutor.UpdateReplicationController(rc)
+}
+
+func resumeReplicationController(rc api.ReplicationController) (api.ReplicationController, error) {
+	replicas, err := pausedReplicas(rc)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	delete(rc.ObjectMeta.Annotations, pausedAnnotation)
+	rc.Spec.Replicas = replicas
+	return DefaultExecutor.UpdateReplicationController(rc)
+}
+
+func pausedReplicas(rc api.ReplicationController) (int, error) {
+	replicas, err := strconv.Atoi(rc.ObjectMeta.Annotations[pausedAnnotation])
+	if err != nil || replicas < 0 {
+		return 0, fmt.Errorf("unreadable paused replicas for service '%v'", rc.ObjectMeta.Name)
+	}
+	return replicas, nil
+}
+
+func serviceDeployment(rc api.ReplicationController) (pmxadapter.ServiceDeployment, error) {
+	status, err := statusFromReplicationController(rc)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	sd := pmxadapter.ServiceDeployment{
+		ID:          rc.ObjectMeta.Name,
+		ActualState: status,
+	}
+	return sd, nil
+}
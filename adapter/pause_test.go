@@ -0,0 +1,193 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessfulPauseService(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Replicas = 3
+	sd, err := adapter.PauseService("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, pmxadapter.ServiceDeployment{ID: "test-service", ActualState: "paused"}, sd)
+	assert.Equal(t, 0, te.RCs[0].Spec.Replicas)
+	assert.Equal(t, "3", te.RCs[0].ObjectMeta.Annotations[pausedAnnotation])
+}
+
+func TestPausedServiceRepausing(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Replicas = 3
+	adapter.PauseService("test-service")
+	_, err := adapter.PauseService("test-service")
+
+	assert.NoError(t, err)
+	assert.Len(t, te.UpdatedRCs, 1)
+	assert.Equal(t, "3", te.RCs[0].ObjectMeta.Annotations[pausedAnnotation])
+}
+
+func TestErroredUpdatingPauseService(t *testing.T) {
+	setupRCs()
+	te.RCs[0].ObjectMeta.Annotations = map[string]string{updateAnnotation: `{}`}
+	_, err := adapter.PauseService("test-service")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestSuccessfulResumeService(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Replicas = 3
+	adapter.PauseService("test-service")
+	sd, err := adapter.ResumeService("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", sd.ActualState)
+	assert.Equal(t, 3, te.RCs[0].Spec.Replicas)
+	assert.Empty(t, te.RCs[0].ObjectMeta.Annotations[pausedAnnotation])
+}
+
+func TestErroredPolicyResumeService(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Replicas = 3
+	adapter.PauseService("test-service")
+	policy.MaxReplicasPerService = 2
+	te.UpdatedRCs = nil
+	_, err := adapter.ResumeService("test-service")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+	assert.Equal(t, 0, te.RCs[0].Spec.Replicas)
+}
+
+func TestErroredApplicationPolicyResumeService(t *testing.T) {
+	setupApplications()
+	policy.MaxReplicasPerApplication = 2
+	adapter.PauseService("db")
+	_, err := adapter.ScaleService("web", 2, false)
+	assert.NoError(t, err)
+	te.UpdatedRCs = nil
+	_, err = adapter.ResumeService("db")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+		assert.Contains(t, err.(*pmxadapter.Error).Message, "application would have 3 replicas")
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestErroredNotPausedResumeService(t *testing.T) {
+	setupRCs()
+	_, err := adapter.ResumeService("test-service")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+}
+
+func TestErroredPausedScaleService(t *testing.T) {
+	setupRCs()
+	adapter.PauseService("test-service")
+	_, err := adapter.ScaleService("test-service", 2, false)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.ScaledID)
+}
+
+func TestPausedGetServiceDetail(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Replicas = 3
+	adapter.PauseService("test-service")
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paused", sd.ActualState)
+	if assert.NotNil(t, sd.PausedReplicas) {
+		assert.Equal(t, 3, *sd.PausedReplicas)
+	}
+}
+
+func TestSuccessfulPauseApplication(t *testing.T) {
+	setupApplications()
+	ad, err := adapter.PauseApplication("app-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paused", ad.ActualState)
+	if assert.Len(t, te.UpdatedRCs, 2) {
+		assert.Equal(t, "web", te.UpdatedRCs[0].ObjectMeta.Name)
+		assert.Equal(t, "db", te.UpdatedRCs[1].ObjectMeta.Name)
+	}
+	assert.Equal(t, 1, te.RCs[2].Spec.Replicas)
+}
+
+func TestErroredUpdatingPauseApplication(t *testing.T) {
+	setupApplications()
+	te.RCs[1].ObjectMeta.Annotations[updateAnnotation] = `{}`
+	_, err := adapter.PauseApplication("app-1")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestSuccessfulResumeApplication(t *testing.T) {
+	setupApplications()
+	adapter.PauseApplication("app-1")
+	te.UpdatedRCs = nil
+	ad, err := adapter.ResumeApplication("app-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "running", ad.ActualState)
+	if assert.Len(t, te.UpdatedRCs, 2) {
+		assert.Equal(t, "db", te.UpdatedRCs[0].ObjectMeta.Name)
+		assert.Equal(t, "web", te.UpdatedRCs[1].ObjectMeta.Name)
+	}
+	for _, rc := range te.RCs {
+		assert.Equal(t, 1, rc.Spec.Replicas)
+	}
+}
+
+func TestErroredPolicyResumeApplication(t *testing.T) {
+	setupApplications()
+	adapter.PauseApplication("app-1")
+	policy.MaxReplicasPerApplication = 1
+	te.UpdatedRCs = nil
+	_, err := adapter.ResumeApplication("app-1")
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestPartlyPausedApplicationStatus(t *testing.T) {
+	sds := []pmxadapter.ServiceDeployment{
+		{ID: "web", ActualState: "paused"},
+		{ID: "db", ActualState: "running 1/1"},
+	}
+	assert.Equal(t, "degraded", applicationStatus(sds))
+	assert.Equal(t, "paused", applicationStatus(sds[:1]))
+}
+
+func TestPausedStatusFromReplicationController(t *testing.T) {
+	adapterSetup()
+	rc := api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: "test-service", Annotations: map[string]string{pausedAnnotation: "2"}},
+	}
+	status, err := statusFromReplicationController(rc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paused", status)
+}
@@ -0,0 +1,119 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+const (
+	nodeSelectorVariable = extensionPrefix + "NODE_SELECTOR"
+	hostVariable         = extensionPrefix + "HOST"
+)
+
+// defaultNodeSelector, from DEFAULT_NODE_SELECTOR, applies to every service.
+// Services can add to it, or override its values.
+var defaultNodeSelector = map[string]string{}
+
+// placementFromService works out where a service's pods may run: on nodes
+// with all of the labels in PANAMAX_NODE_SELECTOR, as a comma-separated list
+// of key=value pairs, and on the node named by PANAMAX_HOST, if any.
+func placementFromService(s pmxadapter.Service) (map[string]string, string, error) {
+	selector := map[string]string{}
+	for k, v := range defaultNodeSelector {
+		selector[k] = v
+	}
+
+	if value, exists := extensionValue(s, nodeSelectorVariable); exists {
+		parsed, err := parseNodeSelector(value)
+		if err != nil {
+			return nil, "", newExtensionError(fmt.Sprintf("%v %v", nodeSelectorVariable, err))
+		}
+		for k, v := range parsed {
+			selector[k] = v
+		}
+	}
+
+	host, _ := extensionValue(s, hostVariable)
+	if len(selector) == 0 {
+		selector = nil
+	}
+	return selector, host, nil
+}
+
+func parseNodeSelector(value string) (map[string]string, error) {
+	selector := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || !util.IsQualifiedName(kv[0]) || !util.IsValidLabelValue(kv[1]) {
+			return nil, fmt.Errorf("must be a list of label=value pairs, not '%v'", value)
+		}
+		selector[kv[0]] = kv[1]
+	}
+
+	return selector, nil
+}
+
+// validatePlacement makes sure some node can take each service's pods, since
+// the scheduler would otherwise leave them pending without complaint.
+func validatePlacement(rcSpecs []api.ReplicationController) error {
+	constrained := false
+	for _, rc := range rcSpecs {
+		podSpec := rc.Spec.Template.Spec
+		if len(podSpec.NodeSelector) > 0 || podSpec.Host != "" {
+			constrained = true
+		}
+	}
+	if !constrained {
+		return nil
+	}
+
+	nodes, err := DefaultExecutor.GetNodes()
+	if err != nil {
+		return err
+	}
+
+	for _, rc := range rcSpecs {
+		podSpec := rc.Spec.Template.Spec
+		selector := labels.SelectorFromSet(labels.Set(podSpec.NodeSelector))
+
+		matched := false
+		for _, n := range nodes {
+			if (podSpec.Host == "" || n.ObjectMeta.Name == podSpec.Host) && selector.Matches(labels.Set(n.ObjectMeta.Labels)) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		constraints := make([]string, 0, 2)
+		if podSpec.Host != "" {
+			constraints = append(constraints, fmt.Sprintf("host '%v'", podSpec.Host))
+		}
+		if len(podSpec.NodeSelector) > 0 {
+			constraints = append(constraints, fmt.Sprintf("node selector '%v'", formatNodeSelector(podSpec.NodeSelector)))
+		}
+		msg := fmt.Sprintf("no node matches the placement of service '%v' (%v)", rc.ObjectMeta.Name, strings.Join(constraints, ", "))
+		return pmxadapter.NewError(http.StatusBadRequest, msg)
+	}
+
+	return nil
+}
+
+func formatNodeSelector(selector map[string]string) string {
+	pairs := make([]string, 0, len(selector))
+	for k, v := range selector {
+		pairs = append(pairs, k+"="+v)
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,98 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupNodes() {
+	servicesSetup()
+	te.Nodes = []api.Node{
+		{ObjectMeta: api.ObjectMeta{Name: "node-1", Labels: map[string]string{"disk": "ssd", "zone": "a"}}},
+		{ObjectMeta: api.ObjectMeta{Name: "node-2", Labels: map[string]string{"disk": "hdd", "zone": "b"}}},
+	}
+}
+
+func TestPlacementFromService(t *testing.T) {
+	servicesSetup()
+	defaultNodeSelector = map[string]string{"zone": "a", "env": "prod"}
+	s := withEnvironment(*services[0],
+		"PANAMAX_NODE_SELECTOR", "disk=ssd, zone=b",
+		"PANAMAX_HOST", "node-2",
+	)
+	selector, host, err := placementFromService(s)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"disk": "ssd", "zone": "b", "env": "prod"}, selector)
+	assert.Equal(t, "node-2", host)
+}
+
+func TestUnconstrainedPlacementFromService(t *testing.T) {
+	servicesSetup()
+	selector, host, err := placementFromService(*services[0])
+
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+	assert.Empty(t, host)
+}
+
+func TestErroredPlacementFromService(t *testing.T) {
+	for _, value := range []string{"ssd", "disk=ssd,", "disk=s s d", "=ssd"} {
+		servicesSetup()
+		_, _, err := placementFromService(withEnvironment(*services[0], "PANAMAX_NODE_SELECTOR", value))
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr, value) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		}
+	}
+}
+
+func TestSuccessfulValidatePlacement(t *testing.T) {
+	setupNodes()
+	s := withEnvironment(*services[0], "PANAMAX_NODE_SELECTOR", "disk=ssd")
+	rcSpec, _ := replicationControllerSpecFromService(s)
+
+	assert.NoError(t, validatePlacement([]api.ReplicationController{rcSpec}))
+}
+
+func TestUnconstrainedValidatePlacement(t *testing.T) {
+	setupNodes()
+	te.GetNodesError = errors.New("test error")
+	rcSpec, _ := replicationControllerSpecFromService(*services[0])
+
+	assert.NoError(t, validatePlacement([]api.ReplicationController{rcSpec}))
+}
+
+func TestErroredValidatePlacement(t *testing.T) {
+	setupNodes()
+	s := withEnvironment(*services[0],
+		"PANAMAX_NODE_SELECTOR", "disk=ssd,zone=b",
+		"PANAMAX_HOST", "node-2",
+	)
+	services[0] = &s
+	sd, err := adapter.CreateServices(services)
+
+	assert.Empty(t, sd)
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		assert.Equal(t, "no node matches the placement of service 'test-service' (host 'node-2', node selector 'disk=ssd,zone=b')", pmxErr.Message)
+	}
+	assert.Empty(t, te.KServices)
+}
+
+func TestPlacementReplicationControllerFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_NODE_SELECTOR", "disk=ssd", "PANAMAX_HOST", "node-1")
+	rcSpec, err := replicationControllerSpecFromService(s)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"disk": "ssd"}, rcSpec.Spec.Template.Spec.NodeSelector)
+	assert.Equal(t, "node-1", rcSpec.Spec.Template.Spec.Host)
+}
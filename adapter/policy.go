@@ -0,0 +1,233 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// Docker Hub images have no registry in their name, so policies call it this.
+const dockerHubRegistry = "docker.io"
+
+// A deploymentPolicy limits what templates can deploy. It's read from the JSON
+// file at POLICY_FILE; the zero value allows everything but privileged
+// containers, which the adapter never creates anyway. Registries are shell
+// patterns, and host ports are single ports or ranges like "1-1023".
+type deploymentPolicy struct {
+	AllowedRegistries         []string `json:"allowedRegistries"`
+	DeniedRegistries          []string `json:"deniedRegistries"`
+	ForbiddenHostPorts        []string `json:"forbiddenHostPorts"`
+	MaxReplicasPerService     int      `json:"maxReplicasPerService"`
+	MaxReplicasPerApplication int      `json:"maxReplicasPerApplication"`
+	DeniedVolumeTypes         []string `json:"deniedVolumeTypes"`
+	AllowPrivileged           bool     `json:"allowPrivileged"`
+
+	portRanges [][2]int
+}
+
+var policy deploymentPolicy
+
+func readPolicy(file string) (deploymentPolicy, error) {
+	var p deploymentPolicy
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return p, err
+	}
+
+	for _, r := range append(p.AllowedRegistries, p.DeniedRegistries...) {
+		if _, err := path.Match(r, ""); err != nil {
+			return p, fmt.Errorf("bad registry pattern '%v': %v", r, err)
+		}
+	}
+	for _, ports := range p.ForbiddenHostPorts {
+		r, err := parsePortRange(ports)
+		if err != nil {
+			return p, err
+		}
+		p.portRanges = append(p.portRanges, r)
+	}
+
+	return p, nil
+}
+
+func parsePortRange(ports string) ([2]int, error) {
+	bounds := strings.SplitN(ports, "-", 2)
+	lo, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	hi := lo
+	if err == nil && len(bounds) == 2 {
+		hi, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+	}
+	if err != nil || lo < 1 || hi > 65535 || lo > hi {
+		return [2]int{}, fmt.Errorf("bad host port range '%v'", ports)
+	}
+
+	return [2]int{lo, hi}, nil
+}
+
+// enforcePolicy checks ReplicationController specs built for a deploy against
+// the policy, rejecting the whole deploy with every violation found.
+func enforcePolicy(rcSpecs []api.ReplicationController) error {
+	violations := policy.violations(rcSpecs)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	msg := "deployment violates policy: " + strings.Join(violations, "; ")
+	return pmxadapter.NewError(http.StatusForbidden, msg)
+}
+
+func (p deploymentPolicy) violations(rcSpecs []api.ReplicationController) []string {
+	violations := make([]string, 0)
+	total := 0
+	for _, rc := range rcSpecs {
+		name := rc.ObjectMeta.Name
+		violated := func(format string, args ...interface{}) {
+			violations = append(violations, fmt.Sprintf("service '%v' ", name)+fmt.Sprintf(format, args...))
+		}
+
+		total += rc.Spec.Replicas
+		if p.MaxReplicasPerService > 0 && rc.Spec.Replicas > p.MaxReplicasPerService {
+			violated("has %v replicas, more than the %v allowed", rc.Spec.Replicas, p.MaxReplicasPerService)
+		}
+
+		for _, v := range rc.Spec.Template.Spec.Volumes {
+			if t := volumeType(v.Source); p.deniesVolumeType(t) {
+				violated("uses a %v volume, which isn't allowed", t)
+			}
+		}
+
+		for _, c := range rc.Spec.Template.Spec.Containers {
+			if c.Privileged && !p.AllowPrivileged {
+				violated("runs a privileged container, which isn't allowed")
+			}
+
+			registry := dockerHubRegistry
+			if ref, err := parseImage(c.Image); err == nil && ref.Registry != "" {
+				registry = ref.Registry
+			}
+			if !p.allowsRegistry(registry) {
+				violated("pulls from registry '%v', which isn't allowed", registry)
+			}
+
+			for _, port := range c.Ports {
+				if port.HostPort != 0 && p.forbidsHostPort(port.HostPort) {
+					violated("uses host port %v, which isn't allowed", port.HostPort)
+				}
+			}
+		}
+	}
+
+	if p.MaxReplicasPerApplication > 0 && total > p.MaxReplicasPerApplication {
+		violations = append(violations, fmt.Sprintf("application has %v replicas, more than the %v allowed", total, p.MaxReplicasPerApplication))
+	}
+
+	return violations
+}
+
+// checkServiceReplicas enforces MaxReplicasPerService on a running service
+// about to be scaled to replicas.
+func checkServiceReplicas(replicas int) error {
+	if policy.MaxReplicasPerService > 0 && replicas > policy.MaxReplicasPerService {
+		msg := fmt.Sprintf("policy allows at most %v replicas per service", policy.MaxReplicasPerService)
+		return pmxadapter.NewError(http.StatusForbidden, msg)
+	}
+	return nil
+}
+
+// checkApplicationReplicas refuses a change that adds replicas to a running
+// service if it would take the total replicas of the service's application
+// past MaxReplicasPerApplication. Replicas of updates in progress count, and a
+// service deployed on its own is an application of one.
+func checkApplicationReplicas(rc api.ReplicationController, added int) error {
+	if policy.MaxReplicasPerApplication == 0 || added <= 0 {
+		return nil
+	}
+
+	rcs, err := DefaultExecutor.GetReplicationControllers()
+	if err != nil {
+		return err
+	}
+	appID := rc.ObjectMeta.Labels[applicationLabel]
+	inApp := map[string]bool{rc.ObjectMeta.Name: true}
+	for _, r := range rcs {
+		if appID != "" && r.ObjectMeta.Labels[applicationLabel] == appID {
+			inApp[r.ObjectMeta.Name] = true
+		}
+	}
+
+	total := added
+	for _, r := range rcs {
+		if inApp[r.ObjectMeta.Name] || isNextTrack(r) && inApp[r.ObjectMeta.Labels["service-name"]] {
+			total += r.Spec.Replicas
+		}
+	}
+	if total > policy.MaxReplicasPerApplication {
+		msg := fmt.Sprintf("application would have %v replicas, more than the %v policy allows", total, policy.MaxReplicasPerApplication)
+		return pmxadapter.NewError(http.StatusForbidden, msg)
+	}
+
+	return nil
+}
+
+func (p deploymentPolicy) allowsRegistry(registry string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, registry); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matches(p.DeniedRegistries) {
+		return false
+	}
+	return len(p.AllowedRegistries) == 0 || matches(p.AllowedRegistries)
+}
+
+func (p deploymentPolicy) forbidsHostPort(port int) bool {
+	for _, r := range p.portRanges {
+		if port >= r[0] && port <= r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p deploymentPolicy) deniesVolumeType(t string) bool {
+	for _, denied := range p.DeniedVolumeTypes {
+		if strings.EqualFold(denied, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Volume types are named as they are in the Kubernetes API. A source with
+// nothing set is an empty directory.
+func volumeType(s api.VolumeSource) string {
+	switch {
+	case s.HostPath != nil:
+		return "hostPath"
+	case s.GCEPersistentDisk != nil:
+		return "persistentDisk"
+	case s.GitRepo != nil:
+		return "gitRepo"
+	case s.Secret != nil:
+		return "secret"
+	}
+
+	return "emptyDir"
+}
@@ -0,0 +1,131 @@
+package adapter
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePolicy(t *testing.T, contents string) string {
+	f, err := ioutil.TempFile("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(contents)
+	f.Close()
+	return f.Name()
+}
+
+func TestReadPolicy(t *testing.T) {
+	file := writePolicy(t, `{"allowedRegistries": ["*.example.com"], "forbiddenHostPorts": ["22", "1000-2000"], "maxReplicasPerService": 5}`)
+	defer os.Remove(file)
+	p, err := readPolicy(file)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*.example.com"}, p.AllowedRegistries)
+	assert.Equal(t, 5, p.MaxReplicasPerService)
+	assert.Equal(t, [][2]int{{22, 22}, {1000, 2000}}, p.portRanges)
+}
+
+func TestErroredReadPolicy(t *testing.T) {
+	for _, contents := range []string{
+		`{`,
+		`{"forbiddenHostPorts": ["2000-1000"]}`,
+		`{"forbiddenHostPorts": ["ssh"]}`,
+		`{"deniedRegistries": ["["]}`,
+	} {
+		file := writePolicy(t, contents)
+		_, err := readPolicy(file)
+		os.Remove(file)
+
+		assert.Error(t, err, contents)
+	}
+}
+
+func TestAllowsRegistry(t *testing.T) {
+	p := deploymentPolicy{
+		AllowedRegistries: []string{"docker.io", "*.example.com"},
+		DeniedRegistries:  []string{"untrusted.example.com"},
+	}
+
+	assert.True(t, p.allowsRegistry("docker.io"))
+	assert.True(t, p.allowsRegistry("registry.example.com"))
+	assert.False(t, p.allowsRegistry("untrusted.example.com"))
+	assert.False(t, p.allowsRegistry("quay.io"))
+	assert.True(t, deploymentPolicy{}.allowsRegistry("quay.io"))
+}
+
+func TestNoViolations(t *testing.T) {
+	servicesSetup()
+	rcSpec, _ := replicationControllerSpecFromService(*services[0])
+
+	assert.Empty(t, deploymentPolicy{}.violations([]api.ReplicationController{rcSpec}))
+}
+
+func TestViolations(t *testing.T) {
+	servicesSetup()
+	rcSpec, _ := replicationControllerSpecFromService(*services[0])
+	rcSpec.Spec.Replicas = 4
+	rcSpec.Spec.Template.Spec.Volumes = []api.Volume{{Source: api.VolumeSource{HostPath: &api.HostPathVolumeSource{Path: "/"}}}}
+	rcSpec.Spec.Template.Spec.Containers[0].Privileged = true
+	other := rcSpec
+	other.ObjectMeta.Name = "other"
+
+	p := deploymentPolicy{
+		DeniedRegistries:          []string{"docker.io"},
+		MaxReplicasPerService:     3,
+		MaxReplicasPerApplication: 6,
+		DeniedVolumeTypes:         []string{"hostpath"},
+		portRanges:                [][2]int{{30000, 32000}},
+	}
+	violations := p.violations([]api.ReplicationController{rcSpec, other})
+
+	assert.Equal(t, []string{
+		"service 'test-service' has 4 replicas, more than the 3 allowed",
+		"service 'test-service' uses a hostPath volume, which isn't allowed",
+		"service 'test-service' runs a privileged container, which isn't allowed",
+		"service 'test-service' pulls from registry 'docker.io', which isn't allowed",
+		"service 'test-service' uses host port 31981, which isn't allowed",
+		"service 'other' has 4 replicas, more than the 3 allowed",
+		"service 'other' uses a hostPath volume, which isn't allowed",
+		"service 'other' runs a privileged container, which isn't allowed",
+		"service 'other' pulls from registry 'docker.io', which isn't allowed",
+		"service 'other' uses host port 31981, which isn't allowed",
+		"application has 8 replicas, more than the 6 allowed",
+	}, violations)
+}
+
+func TestErroredPolicyCreateServices(t *testing.T) {
+	servicesSetup()
+	services[0].Source = "registry.example.com/app"
+	policy = deploymentPolicy{DeniedRegistries: []string{"*.example.com"}}
+	sd, err := adapter.CreateServices(services)
+
+	assert.Empty(t, sd)
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, pmxErr.Code)
+		assert.Equal(t, "deployment violates policy: service 'test-service' pulls from registry 'registry.example.com', which isn't allowed", pmxErr.Message)
+	}
+	assert.Empty(t, tr.CheckedImages)
+	assert.Empty(t, te.Secrets)
+	assert.Empty(t, te.KServices)
+	assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+}
+
+func TestErroredPolicyScaleService(t *testing.T) {
+	setupRCs()
+	policy = deploymentPolicy{MaxReplicasPerService: 3}
+	_, err := adapter.ScaleService("test-service", 4, false)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, pmxErr.Code)
+	}
+	assert.Empty(t, te.ScaledID)
+}
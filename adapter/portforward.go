@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// ForwardPorts listens on local ports and forwards their connections to a
+// running pod of a service, the named one if pod isn't empty, until stop is
+// closed. Ports are given the way client/portforward takes them: "5432"
+// forwards a port to the same one in the pod, and "15432:5432" forwards local
+// port 15432 to the pod's 5432.
+func (a KubernetesAdapter) ForwardPorts(id string, pod string, ports []string, stop <-chan struct{}) error {
+	if len(ports) == 0 {
+		return pmxadapter.NewError(http.StatusBadRequest, "at least one port is required")
+	}
+
+	p, err := runningPod(id, pod)
+	if err != nil {
+		return err
+	}
+
+	return DefaultExecutor.ForwardPorts(p.Status.Host, p.ObjectMeta.Name, ports, stop)
+}
+
+// runningPod finds a pod of a service that something can be run in or
+// connected to, the named one if pod isn't empty.
+func runningPod(id string, pod string) (api.Pod, error) {
+	pods, err := servicePods(id, pod)
+	if err != nil {
+		return api.Pod{}, err
+	}
+
+	for _, p := range pods {
+		if p.Status.Phase == api.PodRunning && p.Status.Host != "" {
+			return p, nil
+		}
+	}
+
+	msg := fmt.Sprintf("no running pods found for service '%v'", id)
+	return api.Pod{}, pmxadapter.NewError(http.StatusConflict, msg)
+}
@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessfulForwardPorts(t *testing.T) {
+	setupExec()
+	err := adapter.ForwardPorts("test-service", "", []string{"5432", "18080:8080"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host-2 pod-b 5432 18080:8080"}, te.ForwardedPorts)
+}
+
+func TestSuccessfulNamedPodForwardPorts(t *testing.T) {
+	setupExec()
+	te.Pods[0] = logsPod("pod-a", "host-1")
+	err := adapter.ForwardPorts("test-service", "pod-a", []string{"5432"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host-1 pod-a 5432"}, te.ForwardedPorts)
+}
+
+func TestErroredNoRunningPodsForwardPorts(t *testing.T) {
+	setupExec()
+	err := adapter.ForwardPorts("test-service", "pod-a", []string{"5432"}, nil)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusConflict, pmxErr.Code)
+	}
+	assert.Empty(t, te.ForwardedPorts)
+}
+
+func TestErroredNoPortsForwardPorts(t *testing.T) {
+	setupExec()
+	err := adapter.ForwardPorts("test-service", "", nil, nil)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+const (
+	livenessProbeVariable  = extensionPrefix + "LIVENESS_PROBE"
+	readinessProbeVariable = extensionPrefix + "READINESS_PROBE"
+	probeDelayVariable     = extensionPrefix + "PROBE_DELAY"
+	probeTimeoutVariable   = extensionPrefix + "PROBE_TIMEOUT"
+
+	// Liveness failures restart the container, so the default gives slow
+	// starters some room before the first check.
+	defaultLivenessDelay = 15
+)
+
+// probesFromService works out a service's liveness and readiness probes. By
+// default both open a TCP connection to the first TCP port the service
+// publishes or exposes, and a service without one gets no probes. Templates
+// can override that with extension variables: PANAMAX_LIVENESS_PROBE and
+// PANAMAX_READINESS_PROBE take tcp:<port>, http:<port>[/path], exec:<command>
+// or none, and PANAMAX_PROBE_DELAY and PANAMAX_PROBE_TIMEOUT take seconds.
+func probesFromService(s pmxadapter.Service) (liveness *api.Probe, readiness *api.Probe, err error) {
+	var timeout int64
+	delay, readinessDelay := int64(defaultLivenessDelay), int64(0)
+	if d, exists := extensionValue(s, probeDelayVariable); exists {
+		if delay, err = probeSeconds(probeDelayVariable, d); err != nil {
+			return nil, nil, err
+		}
+		readinessDelay = delay
+	}
+	if t, exists := extensionValue(s, probeTimeoutVariable); exists {
+		if timeout, err = probeSeconds(probeTimeoutVariable, t); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	liveness, err = probeFromService(s, livenessProbeVariable)
+	if err != nil {
+		return nil, nil, err
+	}
+	readiness, err = probeFromService(s, readinessProbeVariable)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if liveness != nil {
+		liveness.InitialDelaySeconds = delay
+		liveness.TimeoutSeconds = timeout
+	}
+	if readiness != nil {
+		readiness.InitialDelaySeconds = readinessDelay
+		readiness.TimeoutSeconds = timeout
+	}
+
+	return liveness, readiness, nil
+}
+
+func probeFromService(s pmxadapter.Service, variable string) (*api.Probe, error) {
+	spec, exists := extensionValue(s, variable)
+	if !exists {
+		for _, p := range s.Ports {
+			if p.Protocol == "" || strings.ToUpper(p.Protocol) == string(api.ProtocolTCP) {
+				return tcpProbe(int(p.ContainerPort)), nil
+			}
+		}
+		if len(s.Expose) > 0 {
+			return tcpProbe(int(s.Expose[0])), nil
+		}
+		return nil, nil
+	}
+
+	if spec == "none" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, newExtensionError(fmt.Sprintf("%v must be tcp:<port>, http:<port>[/path], exec:<command> or none, not '%v'", variable, spec))
+	}
+
+	switch parts[0] {
+	case "tcp":
+		port, err := probePort(variable, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return tcpProbe(port), nil
+	case "http":
+		portAndPath := strings.SplitN(parts[1], "/", 2)
+		port, err := probePort(variable, portAndPath[0])
+		if err != nil {
+			return nil, err
+		}
+		path := "/"
+		if len(portAndPath) == 2 {
+			path += portAndPath[1]
+		}
+		return &api.Probe{
+			Handler: api.Handler{
+				HTTPGet: &api.HTTPGetAction{Port: util.NewIntOrStringFromInt(port), Path: path},
+			},
+		}, nil
+	case "exec":
+		return &api.Probe{
+			Handler: api.Handler{
+				Exec: &api.ExecAction{Command: strings.Fields(parts[1])},
+			},
+		}, nil
+	}
+
+	return nil, newExtensionError(fmt.Sprintf("%v has unknown probe type '%v'", variable, parts[0]))
+}
+
+func tcpProbe(port int) *api.Probe {
+	return &api.Probe{
+		Handler: api.Handler{
+			TCPSocket: &api.TCPSocketAction{Port: util.NewIntOrStringFromInt(port)},
+		},
+	}
+}
+
+func probePort(variable string, value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, newExtensionError(fmt.Sprintf("%v has invalid port '%v'", variable, value))
+	}
+
+	return port, nil
+}
+
+func probeSeconds(variable string, value string) (int64, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, newExtensionError(fmt.Sprintf("%v must be a number of seconds, not '%v'", variable, value))
+	}
+
+	return seconds, nil
+}
@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func withEnvironment(s pmxadapter.Service, env ...string) pmxadapter.Service {
+	for i := 0; i < len(env); i += 2 {
+		s.Environment = append(s.Environment, &pmxadapter.Environment{Variable: env[i], Value: env[i+1]})
+	}
+	return s
+}
+
+func TestDefaultProbesFromService(t *testing.T) {
+	servicesSetup()
+	liveness, readiness, err := probesFromService(*services[0])
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, liveness) && assert.NotNil(t, liveness.TCPSocket) {
+		assert.Equal(t, 12345, liveness.TCPSocket.Port.IntVal)
+		assert.EqualValues(t, defaultLivenessDelay, liveness.InitialDelaySeconds)
+	}
+	if assert.NotNil(t, readiness) && assert.NotNil(t, readiness.TCPSocket) {
+		assert.Equal(t, 12345, readiness.TCPSocket.Port.IntVal)
+		assert.EqualValues(t, 0, readiness.InitialDelaySeconds)
+	}
+}
+
+func TestExposedPortDefaultProbesFromService(t *testing.T) {
+	servicesSetup()
+	s := *services[0]
+	s.Ports = []*pmxadapter.Port{{HostPort: 5353, ContainerPort: 53, Protocol: "UDP"}}
+	s.Expose = []uint16{8080}
+	liveness, _, err := probesFromService(s)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, liveness) && assert.NotNil(t, liveness.TCPSocket) {
+		assert.Equal(t, 8080, liveness.TCPSocket.Port.IntVal)
+	}
+}
+
+func TestNoPortsProbesFromService(t *testing.T) {
+	servicesSetup()
+	s := *services[0]
+	s.Ports = nil
+	liveness, readiness, err := probesFromService(s)
+
+	assert.NoError(t, err)
+	assert.Nil(t, liveness)
+	assert.Nil(t, readiness)
+}
+
+func TestConfiguredProbesFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0],
+		"PANAMAX_LIVENESS_PROBE", "http:8080/healthz",
+		"PANAMAX_READINESS_PROBE", "exec:cat /tmp/ready",
+		"PANAMAX_PROBE_DELAY", "30",
+		"PANAMAX_PROBE_TIMEOUT", "5",
+	)
+	liveness, readiness, err := probesFromService(s)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, liveness) && assert.NotNil(t, liveness.HTTPGet) {
+		assert.Equal(t, 8080, liveness.HTTPGet.Port.IntVal)
+		assert.Equal(t, "/healthz", liveness.HTTPGet.Path)
+		assert.EqualValues(t, 30, liveness.InitialDelaySeconds)
+		assert.EqualValues(t, 5, liveness.TimeoutSeconds)
+	}
+	if assert.NotNil(t, readiness) && assert.NotNil(t, readiness.Exec) {
+		assert.Equal(t, []string{"cat", "/tmp/ready"}, readiness.Exec.Command)
+		assert.EqualValues(t, 30, readiness.InitialDelaySeconds)
+	}
+}
+
+func TestDisabledProbesFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_LIVENESS_PROBE", "none")
+	liveness, readiness, err := probesFromService(s)
+
+	assert.NoError(t, err)
+	assert.Nil(t, liveness)
+	assert.NotNil(t, readiness)
+}
+
+func TestErroredProbesFromService(t *testing.T) {
+	for _, env := range [][]string{
+		{"PANAMAX_LIVENESS_PROBE", "udp:53"},
+		{"PANAMAX_LIVENESS_PROBE", "tcp"},
+		{"PANAMAX_READINESS_PROBE", "http:port/path"},
+		{"PANAMAX_READINESS_PROBE", "tcp:70000"},
+		{"PANAMAX_PROBE_DELAY", "soon"},
+		{"PANAMAX_PROBE_TIMEOUT", "-1"},
+	} {
+		servicesSetup()
+		_, _, err := probesFromService(withEnvironment(*services[0], env...))
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr, env[1]) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+			assert.Contains(t, pmxErr.Message, env[0])
+		}
+	}
+}
+
+func TestExtensionVariablesStrippedReplicationControllerFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_LIVENESS_PROBE", "none")
+	spec, err := replicationControllerSpecFromService(s)
+
+	assert.NoError(t, err)
+	c := spec.Spec.Template.Spec.Containers[0]
+	if assert.Len(t, c.Env, 1) {
+		assert.Equal(t, "VAR_NAME", c.Env[0].Name)
+	}
+	assert.Nil(t, c.LivenessProbe)
+	assert.NotNil(t, c.ReadinessProbe)
+}
+
+func TestUnknownExtensionVariablesPassedReplicationControllerFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_API_URL", "http://panamax")
+	spec, err := replicationControllerSpecFromService(s)
+
+	assert.NoError(t, err)
+	c := spec.Spec.Template.Spec.Containers[0]
+	if assert.Len(t, c.Env, 2) {
+		assert.Equal(t, api.EnvVar{Name: "PANAMAX_API_URL", Value: "http://panamax"}, c.Env[1])
+	}
+}
+
+func TestErroredProbesCreateServices(t *testing.T) {
+	servicesSetup()
+	services[0].Environment = append(services[0].Environment, &pmxadapter.Environment{Variable: "PANAMAX_LIVENESS_PROBE", Value: "bogus"})
+	sd, err := adapter.CreateServices(services)
+
+	assert.Len(t, sd, 0)
+	assert.Error(t, err)
+	assert.Empty(t, te.KServices)
+	assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+}
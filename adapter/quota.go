@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+)
+
+// checkNamespaceLimits makes sure a whole deploy fits within the namespace's
+// ResourceQuotas and LimitRanges before any of it is created, since the API
+// server would otherwise refuse whichever object happened to cross the line,
+// leaving the rest behind. Containers without a limit count as using none.
+func checkNamespaceLimits(rcSpecs []api.ReplicationController, kServices []api.Service) error {
+	quotas, err := DefaultExecutor.GetResourceQuotas()
+	if err != nil {
+		return err
+	}
+	limitRanges, err := DefaultExecutor.GetLimitRanges()
+	if err != nil {
+		return err
+	}
+
+	needed := map[api.ResourceName]int64{
+		api.ResourceReplicationControllers: int64(len(rcSpecs)),
+		api.ResourceServices:               int64(len(kServices)),
+	}
+	for _, rc := range rcSpecs {
+		replicas := int64(rc.Spec.Replicas)
+		needed[api.ResourcePods] += replicas
+		for name, used := range podLimits(rc.Spec.Template.Spec) {
+			needed[name] += replicas * used
+		}
+	}
+
+	problems := make([]string, 0)
+	for _, q := range quotas {
+		problems = append(problems, quotaProblems(q, needed)...)
+	}
+	for _, lr := range limitRanges {
+		problems = append(problems, limitRangeProblems(lr, rcSpecs)...)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	msg := "deployment doesn't fit in the namespace: " + strings.Join(problems, "; ")
+	return pmxadapter.NewError(http.StatusForbidden, msg)
+}
+
+func quotaProblems(q api.ResourceQuota, needed map[api.ResourceName]int64) []string {
+	// The status is what's enforced, but it's only there once the quota
+	// controller has been around to fill it in.
+	hard := q.Status.Hard
+	if len(hard) == 0 {
+		hard = q.Spec.Hard
+	}
+
+	problems := make([]string, 0)
+	for _, name := range sortedResourceNames(hard) {
+		n, tracked := needed[name]
+		if !tracked || n == 0 {
+			continue
+		}
+
+		limit := hard[name]
+		available := quantityValue(name, &limit)
+		if used, exists := q.Status.Used[name]; exists {
+			available -= quantityValue(name, &used)
+		}
+		if n > available {
+			problems = append(problems, fmt.Sprintf(
+				"quota '%v' has room for %v more %v, and the deploy needs %v",
+				q.ObjectMeta.Name, formatQuantity(name, available), name, formatQuantity(name, n),
+			))
+		}
+	}
+
+	return problems
+}
+
+func limitRangeProblems(lr api.LimitRange, rcSpecs []api.ReplicationController) []string {
+	problems := make([]string, 0)
+	for _, item := range lr.Spec.Limits {
+		for _, rc := range rcSpecs {
+			podSpec := rc.Spec.Template.Spec
+			var usages []map[api.ResourceName]int64
+			switch item.Type {
+			case api.LimitTypePod:
+				usages = append(usages, podLimits(podSpec))
+			case api.LimitTypeContainer:
+				for _, c := range podSpec.Containers {
+					usages = append(usages, containerLimits(c))
+				}
+			default:
+				continue
+			}
+
+			for _, usage := range usages {
+				for _, name := range sortedResourceNames(item.Min) {
+					min := item.Min[name]
+					if usage[name] < quantityValue(name, &min) {
+						problems = append(problems, fmt.Sprintf(
+							"limit range '%v' needs each %v to be limited to at least %v %v, but service '%v' is limited to %v",
+							lr.ObjectMeta.Name, strings.ToLower(string(item.Type)), min.String(), name, rc.ObjectMeta.Name, formatQuantity(name, usage[name]),
+						))
+					}
+				}
+				for _, name := range sortedResourceNames(item.Max) {
+					max := item.Max[name]
+					if usage[name] > quantityValue(name, &max) {
+						problems = append(problems, fmt.Sprintf(
+							"limit range '%v' allows each %v at most %v %v, but service '%v' is limited to %v",
+							lr.ObjectMeta.Name, strings.ToLower(string(item.Type)), max.String(), name, rc.ObjectMeta.Name, formatQuantity(name, usage[name]),
+						))
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+func podLimits(podSpec api.PodSpec) map[api.ResourceName]int64 {
+	total := map[api.ResourceName]int64{}
+	for _, c := range podSpec.Containers {
+		for name, used := range containerLimits(c) {
+			total[name] += used
+		}
+	}
+
+	return total
+}
+
+func containerLimits(c api.Container) map[api.ResourceName]int64 {
+	limits := map[api.ResourceName]int64{}
+	for name, q := range c.Resources.Limits {
+		limits[name] = quantityValue(name, &q)
+	}
+
+	return limits
+}
+
+// CPU is compared in thousandths of a core, and everything else in whole
+// units.
+func quantityValue(name api.ResourceName, q *resource.Quantity) int64 {
+	if name == api.ResourceCPU {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
+func formatQuantity(name api.ResourceName, value int64) string {
+	switch name {
+	case api.ResourceCPU:
+		return resource.NewMilliQuantity(value, resource.DecimalSI).String()
+	case api.ResourceMemory:
+		return resource.NewQuantity(value, resource.BinarySI).String()
+	}
+	return fmt.Sprint(value)
+}
+
+// Maps have no order, and problems should be reported the same way twice.
+func sortedResourceNames(list api.ResourceList) []api.ResourceName {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	sorted := make([]api.ResourceName, len(names))
+	for i, name := range names {
+		sorted[i] = api.ResourceName(name)
+	}
+	return sorted
+}
@@ -0,0 +1,122 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupQuotas() []api.ReplicationController {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_CPU_LIMIT", "500m", "PANAMAX_MEMORY_LIMIT", "256Mi")
+	s.Deployment.Count = 2
+	rcSpec, _ := replicationControllerSpecFromService(s)
+	return []api.ReplicationController{rcSpec}
+}
+
+func quota(name string, hard api.ResourceList, used api.ResourceList) api.ResourceQuota {
+	return api.ResourceQuota{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Status:     api.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestFittingCheckNamespaceLimits(t *testing.T) {
+	rcSpecs := setupQuotas()
+	te.ResourceQuotas = []api.ResourceQuota{quota("q",
+		api.ResourceList{api.ResourcePods: resource.MustParse("10"), api.ResourceCPU: resource.MustParse("2")},
+		api.ResourceList{api.ResourcePods: resource.MustParse("8"), api.ResourceCPU: resource.MustParse("1")},
+	)}
+	te.LimitRanges = []api.LimitRange{{
+		ObjectMeta: api.ObjectMeta{Name: "lr"},
+		Spec: api.LimitRangeSpec{Limits: []api.LimitRangeItem{{
+			Type: api.LimitTypeContainer,
+			Max:  api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+		}}},
+	}}
+
+	assert.NoError(t, checkNamespaceLimits(rcSpecs, nil))
+}
+
+func TestExceededQuotaCheckNamespaceLimits(t *testing.T) {
+	rcSpecs := setupQuotas()
+	te.ResourceQuotas = []api.ResourceQuota{quota("q",
+		api.ResourceList{
+			api.ResourcePods:     resource.MustParse("10"),
+			api.ResourceServices: resource.MustParse("5"),
+			api.ResourceCPU:      resource.MustParse("2"),
+			api.ResourceMemory:   resource.MustParse("1Gi"),
+		},
+		api.ResourceList{
+			api.ResourcePods:     resource.MustParse("9"),
+			api.ResourceServices: resource.MustParse("5"),
+			api.ResourceCPU:      resource.MustParse("1500m"),
+		},
+	)}
+	err := checkNamespaceLimits(rcSpecs, []api.Service{{}})
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, pmxErr.Code)
+		assert.Equal(t, "deployment doesn't fit in the namespace: "+
+			"quota 'q' has room for 500m more cpu, and the deploy needs 1; "+
+			"quota 'q' has room for 1 more pods, and the deploy needs 2; "+
+			"quota 'q' has room for 0 more services, and the deploy needs 1",
+			pmxErr.Message)
+	}
+}
+
+func TestUnobservedQuotaCheckNamespaceLimits(t *testing.T) {
+	rcSpecs := setupQuotas()
+	te.ResourceQuotas = []api.ResourceQuota{{
+		ObjectMeta: api.ObjectMeta{Name: "new"},
+		Spec:       api.ResourceQuotaSpec{Hard: api.ResourceList{api.ResourceReplicationControllers: resource.MustParse("0")}},
+	}}
+	err := checkNamespaceLimits(rcSpecs, nil)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "quota 'new' has room for 0 more replicationcontrollers, and the deploy needs 1")
+	}
+}
+
+func TestExceededLimitRangeCheckNamespaceLimits(t *testing.T) {
+	rcSpecs := setupQuotas()
+	te.LimitRanges = []api.LimitRange{{
+		ObjectMeta: api.ObjectMeta{Name: "lr"},
+		Spec: api.LimitRangeSpec{Limits: []api.LimitRangeItem{
+			{
+				Type: api.LimitTypeContainer,
+				Max:  api.ResourceList{api.ResourceMemory: resource.MustParse("128Mi")},
+			},
+			{
+				Type: api.LimitTypePod,
+				Min:  api.ResourceList{api.ResourceCPU: resource.MustParse("1")},
+			},
+		}},
+	}}
+	err := checkNamespaceLimits(rcSpecs, nil)
+
+	if pmxErr, ok := err.(*pmxadapter.Error); assert.True(t, ok) {
+		assert.Equal(t, "deployment doesn't fit in the namespace: "+
+			"limit range 'lr' allows each container at most 128Mi memory, but service 'test-service' is limited to 256Mi; "+
+			"limit range 'lr' needs each pod to be limited to at least 1 cpu, but service 'test-service' is limited to 500m",
+			pmxErr.Message)
+	}
+}
+
+func TestErroredQuotaCreateServices(t *testing.T) {
+	servicesSetup()
+	te.ResourceQuotas = []api.ResourceQuota{quota("q",
+		api.ResourceList{api.ResourcePods: resource.MustParse("0")}, nil,
+	)}
+	sd, err := adapter.CreateServices(services)
+
+	assert.Empty(t, sd)
+	assert.Error(t, err)
+	assert.Empty(t, te.KServices)
+	assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+}
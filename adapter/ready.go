@@ -0,0 +1,177 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+const (
+	// How long a create waits for its services to be ready when it isn't
+	// told, and the longest it can be told to.
+	DefaultReadyTimeout = 5 * time.Minute
+	MaxReadyTimeout     = 30 * time.Minute
+)
+
+// waitForReady blocks until each ReplicationController has as many ready pods
+// as it wants, or until timeout passes. Pods are watched rather than polled,
+// so it hears about them as they change; selector has to take in every pod of
+// every ReplicationController. When the watch ends early, as the apiserver
+// ends them from time to time, the pods are listed again and a new watch is
+// started.
+func waitForReady(selector labels.Selector, rcs []api.ReplicationController, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	pods := map[string]api.Pod{}
+	for {
+		w, err := DefaultExecutor.WatchPods(selector, "")
+		if err != nil {
+			return err
+		}
+
+		// Listing once the watch has started means nothing can happen
+		// between the two without the watch hearing about it.
+		listed, err := DefaultExecutor.GetPods(selector)
+		if err != nil {
+			w.Stop()
+			return err
+		}
+		pods = map[string]api.Pod{}
+		for _, p := range listed {
+			pods[p.ObjectMeta.Name] = p
+		}
+
+		ready, timedOut := watchUntilReady(w, pods, rcs, deadline)
+		w.Stop()
+		if ready {
+			return nil
+		} else if timedOut {
+			return notReadyError(rcs, pods, timeout)
+		}
+
+		select {
+		case <-deadline:
+			return notReadyError(rcs, pods, timeout)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Applies the watch's events to pods until every ReplicationController is
+// ready, the watch ends or the deadline passes.
+func watchUntilReady(w watch.Interface, pods map[string]api.Pod, rcs []api.ReplicationController, deadline <-chan time.Time) (ready bool, timedOut bool) {
+	for {
+		if len(unreadyReplicationControllers(rcs, pods)) == 0 {
+			return true, false
+		}
+
+		select {
+		case e, ok := <-w.ResultChan():
+			if !ok || e.Type == watch.Error {
+				return false, false
+			}
+			p, ok := e.Object.(*api.Pod)
+			if !ok {
+				continue
+			}
+			if e.Type == watch.Deleted {
+				delete(pods, p.ObjectMeta.Name)
+			} else {
+				pods[p.ObjectMeta.Name] = *p
+			}
+		case <-deadline:
+			return false, true
+		}
+	}
+}
+
+func unreadyReplicationControllers(rcs []api.ReplicationController, pods map[string]api.Pod) []api.ReplicationController {
+	unready := make([]api.ReplicationController, 0)
+	for _, rc := range rcs {
+		if readyPods(rc, pods) < rc.Spec.Replicas {
+			unready = append(unready, rc)
+		}
+	}
+
+	return unready
+}
+
+func readyPods(rc api.ReplicationController, pods map[string]api.Pod) int {
+	selector := labels.SelectorFromSet(labels.Set(rc.Spec.Selector))
+	ready := 0
+	for _, p := range pods {
+		if selector.Matches(labels.Set(p.ObjectMeta.Labels)) && isPodReady(p) {
+			ready++
+		}
+	}
+
+	return ready
+}
+
+// Names each service that isn't ready, with the best explanation to be had
+// from its pods and events.
+func notReadyError(rcs []api.ReplicationController, pods map[string]api.Pod, timeout time.Duration) error {
+	explanations := make([]string, 0)
+	for _, rc := range unreadyReplicationControllers(rcs, pods) {
+		explanation := fmt.Sprintf("'%v' has %v of %v pods ready", rc.ObjectMeta.Name, readyPods(rc, pods), rc.Spec.Replicas)
+		if why := whyNotReady(rc, pods); why != "" {
+			explanation = fmt.Sprintf("%v (%v)", explanation, why)
+		}
+		explanations = append(explanations, explanation)
+	}
+
+	msg := fmt.Sprintf("timed out after %v waiting for services to be ready: %v", timeout, strings.Join(explanations, "; "))
+	return pmxadapter.NewError(http.StatusGatewayTimeout, msg)
+}
+
+// The most recent warning event says the most, and failing that the first
+// pod that isn't ready says what state it's stuck in.
+func whyNotReady(rc api.ReplicationController, pods map[string]api.Pod) string {
+	if events, err := eventsForReplicationController(rc); err == nil {
+		if warnings := recentWarnings(events); len(warnings) > 0 {
+			w := warnings[len(warnings)-1]
+			return fmt.Sprintf("%v: %v", w.Reason, w.Message)
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set(rc.Spec.Selector))
+	for _, p := range pods {
+		if !selector.Matches(labels.Set(p.ObjectMeta.Labels)) || isPodReady(p) {
+			continue
+		}
+		return fmt.Sprintf("pod %v %v", p.ObjectMeta.Name, podProblem(p))
+	}
+
+	return "no pods have been created"
+}
+
+func podProblem(p api.Pod) string {
+	if p.Status.Host == "" {
+		return "isn't scheduled"
+	}
+	for _, c := range p.Status.Info {
+		switch {
+		case c.State.Waiting != nil && c.State.Waiting.Reason != "":
+			return fmt.Sprintf("is waiting: %v", c.State.Waiting.Reason)
+		case c.State.Termination != nil:
+			return fmt.Sprintf("exited with code %v", c.State.Termination.ExitCode)
+		}
+	}
+	if p.Status.Phase == api.PodRunning {
+		return "is failing its readiness probe"
+	}
+
+	phase := p.Status.Phase
+	if phase == "" {
+		phase = api.PodPending
+	}
+	if p.Status.Message != "" {
+		return fmt.Sprintf("is %v: %v", strings.ToLower(string(phase)), p.Status.Message)
+	}
+	return fmt.Sprintf("is %v", strings.ToLower(string(phase)))
+}
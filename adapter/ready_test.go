@@ -0,0 +1,148 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupReady() {
+	servicesSetup()
+	pollInterval = time.Millisecond
+}
+
+func appPod(name string, phase api.PodPhase, host string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"service-name": "test-service", applicationLabel: "test-app"},
+		},
+		Status: api.PodStatus{Phase: phase, Host: host},
+	}
+}
+
+func waitOptions() CreateOptions {
+	return CreateOptions{Wait: true, Timeout: 20 * time.Millisecond}
+}
+
+func TestSuccessfulWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.PodEvents = []watch.Event{
+		{Type: watch.Added, Object: appPod("pod-a", api.PodPending, "")},
+		{Type: watch.Modified, Object: appPod("pod-a", api.PodRunning, "node-1")},
+	}
+	sds, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, te.PodWatches)
+	if assert.Len(t, sds, 1) {
+		assert.Equal(t, "test-service", sds[0].ID)
+	}
+}
+
+func TestAlreadyReadyWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.Pods = []api.Pod{*appPod("pod-a", api.PodRunning, "node-1")}
+	_, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	assert.NoError(t, err)
+}
+
+func TestErroredTimedOutWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.PodEvents = []watch.Event{{Type: watch.Added, Object: appPod("pod-a", api.PodPending, "")}}
+	sds, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	assert.Empty(t, sds)
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusGatewayTimeout, pmxErr.Code)
+		assert.Equal(t, "timed out after 20ms waiting for services to be ready: 'test-service' has 0 of 1 pods ready (pod pod-a isn't scheduled)", pmxErr.Message)
+	}
+}
+
+func TestErroredUnreadyPodWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.PodEvents = []watch.Event{
+		{Type: watch.Added, Object: appPod("pod-b", api.PodRunning, "node-1")},
+	}
+	te.PodEvents[0].Object.(*api.Pod).Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionNone}}
+	_, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Contains(t, err.(*pmxadapter.Error).Message, "pod pod-b is failing its readiness probe")
+	}
+}
+
+func TestWatchUntilReadyDeletedPod(t *testing.T) {
+	rcs := []api.ReplicationController{{Spec: api.ReplicationControllerSpec{Replicas: 1, Selector: map[string]string{"service-name": "test-service"}}}}
+	pods := map[string]api.Pod{}
+	w := &testWatcher{events: make(chan watch.Event, 2)}
+	w.events <- watch.Event{Type: watch.Added, Object: appPod("pod-a", api.PodPending, "node-1")}
+	w.events <- watch.Event{Type: watch.Deleted, Object: appPod("pod-a", api.PodPending, "node-1")}
+	w.Stop()
+	ready, timedOut := watchUntilReady(w, pods, rcs, nil)
+
+	assert.False(t, ready)
+	assert.False(t, timedOut)
+	assert.Empty(t, pods)
+}
+
+func TestErroredWarningWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.Events = map[string][]api.Event{"test-service": {{
+		Reason:        "failedScheduling",
+		Message:       "no nodes fit",
+		LastTimestamp: util.Now(),
+	}}}
+	_, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Contains(t, err.(*pmxadapter.Error).Message, "'test-service' has 0 of 1 pods ready (failedScheduling: no nodes fit)")
+	}
+}
+
+func TestErroredNoPodsWaitingCreateServices(t *testing.T) {
+	setupReady()
+	_, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Contains(t, err.(*pmxadapter.Error).Message, "(no pods have been created)")
+	}
+}
+
+func TestErroredWatchWaitingCreateServices(t *testing.T) {
+	setupReady()
+	te.WatchPodsError = errors.New("test error")
+	_, err := adapter.CreateServicesWithOptions(services, waitOptions())
+
+	assert.EqualError(t, err, "test error")
+}
+
+func TestErroredTimeoutCreateServicesWithOptions(t *testing.T) {
+	setupReady()
+	_, err := adapter.CreateServicesWithOptions(services, CreateOptions{Wait: true, Timeout: MaxReadyTimeout + time.Second})
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+}
+
+func TestPodProblem(t *testing.T) {
+	p := *appPod("pod-a", api.PodPending, "node-1")
+	assert.Equal(t, "is pending", podProblem(p))
+
+	p.Status.Info = api.PodInfo{"test-service": {State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "pulling image"}}}}
+	assert.Equal(t, "is waiting: pulling image", podProblem(p))
+
+	p.Status.Info = api.PodInfo{"test-service": {State: api.ContainerState{Termination: &api.ContainerStateTerminated{ExitCode: 2}}}}
+	assert.Equal(t, "exited with code 2", podProblem(p))
+}
@@ -0,0 +1,150 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+)
+
+var registryClient = &http.Client{Timeout: 10 * time.Second}
+
+// A RegistryChecker asks Docker registries about images. It's kept apart from
+// the Executor, since registries have nothing to do with Kubernetes.
+type RegistryChecker interface {
+	CheckImage(registry string, repository string, tag string, username string, password string) (int, error)
+}
+
+var DefaultRegistryChecker RegistryChecker = HTTPRegistryChecker{}
+
+type HTTPRegistryChecker struct{}
+
+// CheckImage returns the status a registry answers a request for an image's
+// tag with, trying the v2 API before falling back to v1. Registries that only
+// take bearer tokens can't be asked with basic auth, so for those it's zero.
+func (c HTTPRegistryChecker) CheckImage(registry string, repository string, tag string, username string, password string) (int, error) {
+	paths := []string{
+		fmt.Sprintf("/v2/%v/manifests/%v", repository, tag),
+		fmt.Sprintf("/v1/repositories/%v/tags/%v", repository, tag),
+	}
+	for i, path := range paths {
+		req, err := http.NewRequest("GET", "https://"+registry+path, nil)
+		if err != nil {
+			return 0, err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := registryClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer") {
+			return 0, nil
+		}
+		// A v1 registry doesn't know the v2 API at all, whereas a v2 registry
+		// says so even when the image isn't there.
+		isV2 := resp.Header.Get("Docker-Distribution-Api-Version") != ""
+		if i == 0 && resp.StatusCode == http.StatusNotFound && !isV2 {
+			continue
+		}
+
+		return resp.StatusCode, nil
+	}
+
+	return 0, nil
+}
+
+// A registryCredential is what a .dockercfg holds for one registry.
+type registryCredential struct {
+	Username string
+	Password string
+}
+
+// registryCredentials are keyed by registry host, and read from the
+// .dockercfg at DOCKERCFG. Kubelets of this Kubernetes version can't be handed
+// credentials per pod: they read their own node's .dockercfg, which has to
+// hold the same credentials for pulls to succeed.
+var registryCredentials = map[string]registryCredential{}
+
+func readDockercfg(path string) (map[string]registryCredential, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg map[string]struct {
+		Auth string `json:"auth"`
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+
+	creds := make(map[string]registryCredential, len(cfg))
+	for key, entry := range cfg {
+		auth, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("unreadable auth for registry '%v': %v", key, err)
+		}
+		parts := strings.SplitN(string(auth), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("auth for registry '%v' isn't a username and password", key)
+		}
+		creds[registryHost(key)] = registryCredential{Username: parts[0], Password: parts[1]}
+	}
+
+	return creds, nil
+}
+
+// .dockercfg keys may be bare hosts or URLs like https://host/v1/.
+func registryHost(key string) string {
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return strings.SplitN(key, "/", 2)[0]
+}
+
+// validateServicesImages asks each private registry whether the image can be
+// pulled, so a missing credential or a mistyped tag fails the deploy instead
+// of leaving pods pending forever. Docker Hub images aren't checked, and
+// neither are images when the registry can't give a straight answer.
+func validateServicesImages(services []*pmxadapter.Service) error {
+	for _, s := range services {
+		ref, err := parseImage(s.Source)
+		if err != nil {
+			return err
+		}
+		registry := ref.Registry
+		if registry == "" {
+			continue
+		}
+
+		cred, hasCred := registryCredentials[registry]
+		status, err := DefaultRegistryChecker.CheckImage(registry, ref.Repository, ref.Reference(), cred.Username, cred.Password)
+		if err != nil {
+			log.Printf("Couldn't check image '%v': %v", s.Source, err)
+			continue
+		}
+
+		switch {
+		case (status == http.StatusUnauthorized || status == http.StatusForbidden) && !hasCred:
+			return pmxadapter.NewError(http.StatusBadRequest, fmt.Sprintf("image '%v' needs credentials for registry '%v', and none are configured", s.Source, registry))
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return pmxadapter.NewError(http.StatusBadRequest, fmt.Sprintf("registry '%v' refused the configured credentials for image '%v'", registry, s.Source))
+		case status == http.StatusNotFound:
+			return pmxadapter.NewError(http.StatusBadRequest, fmt.Sprintf("image '%v' not found in registry '%v'", s.Source, registry))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestRegistryChecker struct {
+	ImageStatuses map[string]int
+	CheckedImages []string
+}
+
+var tr TestRegistryChecker
+
+func (c *TestRegistryChecker) CheckImage(registry string, repository string, tag string, username string, password string) (int, error) {
+	image := fmt.Sprintf("%v/%v:%v", registry, repository, tag)
+	c.CheckedImages = append(c.CheckedImages, strings.TrimSpace(image+" "+username))
+	status, exists := c.ImageStatuses[image]
+	if !exists {
+		return http.StatusOK, nil
+	}
+	if status < 0 {
+		return 0, errors.New("test error")
+	}
+	return status, nil
+}
+
+func TestReadDockercfg(t *testing.T) {
+	f, err := ioutil.TempFile("", "dockercfg")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{
+		"https://registry.example.com/v1/": {"auth": "dXNlcjpwYXNz", "email": "a@example.com"},
+		"other.example.com:5000": {"auth": "Ym90OnM6Y3JldA=="}
+	}`)
+	f.Close()
+
+	creds, err := readDockercfg(f.Name())
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]registryCredential{
+		"registry.example.com":   {Username: "user", Password: "pass"},
+		"other.example.com:5000": {Username: "bot", Password: "s:cret"},
+	}, creds)
+}
+
+func TestSuccessfulValidateServicesImages(t *testing.T) {
+	servicesSetup()
+	registryCredentials["registry.example.com"] = registryCredential{Username: "user", Password: "pass"}
+	services[0].Source = "registry.example.com/team/app:v1"
+	other := *services[0]
+	other.Source = "redis"
+	err := validateServicesImages(append(services, &other))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"registry.example.com/team/app:v1 user"}, tr.CheckedImages)
+}
+
+func TestUnverifiableValidateServicesImages(t *testing.T) {
+	servicesSetup()
+	services[0].Source = "registry.example.com/app"
+	tr.ImageStatuses = map[string]int{"registry.example.com/app:latest": -1}
+
+	assert.NoError(t, validateServicesImages(services))
+}
+
+func TestErroredValidateServicesImages(t *testing.T) {
+	for status, message := range map[int]string{
+		http.StatusUnauthorized: "none are configured",
+		http.StatusNotFound:     "not found in registry",
+	} {
+		servicesSetup()
+		services[0].Source = "registry.example.com/app"
+		tr.ImageStatuses = map[string]int{"registry.example.com/app:latest": status}
+		sd, err := adapter.CreateServices(services)
+
+		assert.Empty(t, sd)
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+			assert.Contains(t, pmxErr.Message, message)
+		}
+		assert.Empty(t, te.KServices)
+	}
+}
+
+func TestErroredRefusedCredentialsValidateServicesImages(t *testing.T) {
+	servicesSetup()
+	registryCredentials["registry.example.com"] = registryCredential{Username: "user", Password: "wrong"}
+	services[0].Source = "registry.example.com/app"
+	tr.ImageStatuses = map[string]int{"registry.example.com/app:latest": http.StatusForbidden}
+	err := validateServicesImages(services)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "refused the configured credentials")
+	}
+}
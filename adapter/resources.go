@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"fmt"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+)
+
+const (
+	cpuLimitVariable    = extensionPrefix + "CPU_LIMIT"
+	memoryLimitVariable = extensionPrefix + "MEMORY_LIMIT"
+)
+
+// defaultLimits apply to every service that doesn't set its own, and are
+// configured with DEFAULT_CPU_LIMIT and DEFAULT_MEMORY_LIMIT.
+var defaultLimits = api.ResourceList{}
+
+// resourcesFromService works out a service's CPU and memory limits from the
+// PANAMAX_CPU_LIMIT and PANAMAX_MEMORY_LIMIT extension variables, falling back
+// to the adapter's defaults. Values are Kubernetes quantities, like "500m" for
+// half a core or "128Mi". This version of Kubernetes has limits but no
+// requests, so there's nothing else to set.
+func resourcesFromService(s pmxadapter.Service) (api.ResourceRequirements, error) {
+	limits := api.ResourceList{}
+	for name, q := range defaultLimits {
+		limits[name] = q
+	}
+
+	for name, variable := range map[api.ResourceName]string{
+		api.ResourceCPU:    cpuLimitVariable,
+		api.ResourceMemory: memoryLimitVariable,
+	} {
+		value, exists := extensionValue(s, variable)
+		if !exists {
+			continue
+		}
+
+		q, err := parseLimit(value)
+		if err != nil {
+			return api.ResourceRequirements{}, newExtensionError(fmt.Sprintf("%v %v", variable, err))
+		}
+		limits[name] = *q
+	}
+
+	if len(limits) == 0 {
+		return api.ResourceRequirements{}, nil
+	}
+	return api.ResourceRequirements{Limits: limits}, nil
+}
+
+func parseLimit(value string) (*resource.Quantity, error) {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("must be a quantity like '500m' or '128Mi', not '%v'", value)
+	}
+	if q.MilliValue() <= 0 {
+		return nil, fmt.Errorf("must be more than zero, not '%v'", value)
+	}
+
+	return q, nil
+}
+
+// limitsFromReplicationController reports a service's limits in the same form
+// they're configured in.
+func limitsFromReplicationController(rc api.ReplicationController) map[string]string {
+	if rc.Spec.Template == nil || len(rc.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+
+	limits := rc.Spec.Template.Spec.Containers[0].Resources.Limits
+	if len(limits) == 0 {
+		return nil
+	}
+
+	reported := make(map[string]string, len(limits))
+	for name, q := range limits {
+		reported[string(name)] = q.String()
+	}
+	return reported
+}
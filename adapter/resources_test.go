@@ -0,0 +1,80 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoLimitsResourcesFromService(t *testing.T) {
+	servicesSetup()
+	r, err := resourcesFromService(*services[0])
+
+	assert.NoError(t, err)
+	assert.Empty(t, r.Limits)
+}
+
+func TestDefaultResourcesFromService(t *testing.T) {
+	servicesSetup()
+	defaultLimits[api.ResourceCPU] = resource.MustParse("250m")
+	defaultLimits[api.ResourceMemory] = resource.MustParse("64Mi")
+	s := withEnvironment(*services[0], "PANAMAX_MEMORY_LIMIT", "1Gi")
+	r, err := resourcesFromService(s)
+
+	assert.NoError(t, err)
+	cpu := r.Limits[api.ResourceCPU]
+	memory := r.Limits[api.ResourceMemory]
+	assert.EqualValues(t, 250, cpu.MilliValue())
+	assert.EqualValues(t, 1<<30, memory.Value())
+
+	defaulted := defaultLimits[api.ResourceMemory]
+	assert.EqualValues(t, 64<<20, defaulted.Value())
+}
+
+func TestErroredResourcesFromService(t *testing.T) {
+	for _, env := range [][]string{
+		{"PANAMAX_CPU_LIMIT", "lots"},
+		{"PANAMAX_CPU_LIMIT", "0"},
+		{"PANAMAX_MEMORY_LIMIT", "-128Mi"},
+	} {
+		servicesSetup()
+		_, err := resourcesFromService(withEnvironment(*services[0], env...))
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr, env[1]) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+			assert.Contains(t, pmxErr.Message, env[0])
+		}
+	}
+}
+
+func TestLimitsReplicationControllerFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0], "PANAMAX_CPU_LIMIT", "500m")
+	spec, err := replicationControllerSpecFromService(s)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"cpu": "500m"}, limitsFromReplicationController(spec))
+	assert.Len(t, spec.Spec.Template.Spec.Containers[0].Env, 1)
+}
+
+func TestLimitsGetServiceDetail(t *testing.T) {
+	setupRCs()
+	te.RCs[0].Spec.Template = &api.PodTemplateSpec{
+		Spec: api.PodSpec{
+			Containers: []api.Container{{
+				Resources: api.ResourceRequirements{
+					Limits: api.ResourceList{api.ResourceMemory: resource.MustParse("128Mi")},
+				},
+			}},
+		},
+	}
+	sd, err := adapter.GetServiceDetail("test-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"memory": "128Mi"}, sd.Limits)
+}
@@ -0,0 +1,234 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+)
+
+const (
+	revisionsAnnotation = "panamax.io/revisions"
+	revisionLabel       = "panamax-revision"
+	maxRevisions        = 10
+)
+
+// A Revision is one version of a service's pod template. Every deploy and
+// rollback makes a new one, and the most recent few are kept.
+type Revision struct {
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"createdAt"`
+	Image     string    `json:"image"`
+	Current   bool      `json:"current"`
+}
+
+// What's kept in the ReplicationController's annotation: everything needed to
+// go back to the revision.
+type storedRevision struct {
+	Revision   int                 `json:"revision"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	Definition string              `json:"definition,omitempty"`
+	Template   api.PodTemplateSpec `json:"template"`
+}
+
+// GetServiceRevisions lists the revisions kept for a service, oldest first.
+func (a KubernetesAdapter) GetServiceRevisions(id string) ([]Revision, error) {
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return []Revision{}, err
+	}
+	history, err := revisionsFromReplicationController(rc)
+	if err != nil {
+		return []Revision{}, err
+	}
+
+	revisions := make([]Revision, len(history))
+	for i, r := range history {
+		revisions[i] = Revision{
+			Revision:  r.Revision,
+			CreatedAt: r.CreatedAt,
+			Current:   i == len(history)-1,
+		}
+		if len(r.Template.Spec.Containers) > 0 {
+			revisions[i].Image = r.Template.Spec.Containers[0].Image
+		}
+	}
+	return revisions, nil
+}
+
+// RollbackService puts a service back on the pod template of an earlier
+// revision, as a new revision, and replaces its pods one at a time.
+func (a KubernetesAdapter) RollbackService(id string, revision int) (pmxadapter.ServiceDeployment, error) {
+	rc, err := rollBackReplicationController(id, revision)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	return rollOut(rc)
+}
+
+// rollBackReplicationController updates the service's ReplicationController to
+// the revision's template, which has to pass the same checks as a deploy: it
+// may be from before the policy or the registry's credentials changed.
+func rollBackReplicationController(id string, revision int) (api.ReplicationController, error) {
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	history, err := revisionsFromReplicationController(rc)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	var target *storedRevision
+	for i := range history {
+		if history[i].Revision == revision {
+			target = &history[i]
+		}
+	}
+	if target == nil {
+		msg := fmt.Sprintf("revision %v not found for service '%v'", revision, id)
+		return api.ReplicationController{}, pmxadapter.NewNotFoundError(msg)
+	}
+	if target == &history[len(history)-1] {
+		msg := fmt.Sprintf("service '%v' is already at revision %v", id, revision)
+		return api.ReplicationController{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+
+	if _, updating := rc.ObjectMeta.Annotations[updateAnnotation]; updating {
+		msg := fmt.Sprintf("service '%v' is being updated, promote or abort the update first", id)
+		return api.ReplicationController{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+
+	// The revision may predate the service being pinned to a track, and its
+	// pods still have to match the selector.
+	template := copyPodTemplate(target.Template)
+	for k, v := range rc.Spec.Selector {
+		template.ObjectMeta.Labels[k] = v
+	}
+	rc.Spec.Template = &template
+	if err := checkRollback(rc); err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	if target.Definition != "" {
+		rc.ObjectMeta.Annotations[definitionAnnotation] = target.Definition
+	}
+	if err := recordRevision(&rc); err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	return DefaultExecutor.UpdateReplicationController(rc)
+}
+
+func checkRollback(rc api.ReplicationController) error {
+	rcSpecs := []api.ReplicationController{rc}
+	if err := enforcePolicy(rcSpecs); err != nil {
+		return err
+	}
+	images := make([]*pmxadapter.Service, 0)
+	for _, c := range rc.Spec.Template.Spec.Containers {
+		images = append(images, &pmxadapter.Service{Source: c.Image})
+	}
+	if err := validateServicesImages(images); err != nil {
+		return err
+	}
+
+	return validatePlacement(rcSpecs)
+}
+
+// rollOut replaces a service's pods with ones from its updated template.
+func rollOut(rc api.ReplicationController) (pmxadapter.ServiceDeployment, error) {
+	if err := replacePods(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	rc, err := DefaultExecutor.GetReplicationController(rc.ObjectMeta.Name)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	status, err := statusFromReplicationController(rc)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	sd := pmxadapter.ServiceDeployment{
+		ID:          rc.ObjectMeta.Name,
+		ActualState: status,
+	}
+	return sd, nil
+}
+
+func serviceReplicationController(id string) (api.ReplicationController, error) {
+	rc, err := DefaultExecutor.GetReplicationController(id)
+	if err != nil {
+		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonNotFound {
+			return api.ReplicationController{}, pmxadapter.NewNotFoundError(err.Error())
+		}
+
+		return api.ReplicationController{}, err
+	}
+
+	return rc, nil
+}
+
+// Services deployed before revisions were kept have none.
+func revisionsFromReplicationController(rc api.ReplicationController) ([]storedRevision, error) {
+	history := make([]storedRevision, 0)
+	encoded, exists := rc.ObjectMeta.Annotations[revisionsAnnotation]
+	if !exists {
+		return history, nil
+	}
+
+	if err := json.Unmarshal([]byte(encoded), &history); err != nil {
+		return nil, fmt.Errorf("unreadable revisions for service '%v': %v", rc.ObjectMeta.Name, err)
+	}
+	return history, nil
+}
+
+// recordRevision makes the ReplicationController's pod template a new
+// revision, labeling its pods with the revision number so they can be told
+// apart from their predecessors.
+func recordRevision(rc *api.ReplicationController) error {
+	history, err := revisionsFromReplicationController(*rc)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(history) > 0 {
+		next = history[len(history)-1].Revision + 1
+	}
+
+	template := rc.Spec.Template
+	labels := map[string]string{}
+	for k, v := range template.ObjectMeta.Labels {
+		labels[k] = v
+	}
+	labels[revisionLabel] = strconv.Itoa(next)
+	template.ObjectMeta.Labels = labels
+
+	history = append(history, storedRevision{
+		Revision:   next,
+		CreatedAt:  time.Now().UTC(),
+		Definition: rc.ObjectMeta.Annotations[definitionAnnotation],
+		Template:   *template,
+	})
+	if len(history) > maxRevisions {
+		history = history[len(history)-maxRevisions:]
+	}
+
+	encoded, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if rc.ObjectMeta.Annotations == nil {
+		rc.ObjectMeta.Annotations = map[string]string{}
+	}
+	rc.ObjectMeta.Annotations[revisionsAnnotation] = string(encoded)
+	return nil
+}
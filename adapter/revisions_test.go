@@ -0,0 +1,222 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// Leaves test-service at revision 2, running redis:2.8 in two pods, with
+// revision 1 running redis:latest before it.
+func setupRevisions() {
+	servicesSetup()
+	rc, _ := replicationControllerSpecFromService(*services[0])
+	rc.Spec.Replicas = 2
+	recordRevision(&rc)
+	rc.Spec.Template.Spec.Containers[0].Image = "redis:2.8"
+	recordRevision(&rc)
+	te.RCs = []api.ReplicationController{rc}
+	te.Pods = revisionPods()
+	te.ReplacePods = true
+	pollInterval = time.Millisecond
+	replacementTimeout = 20 * time.Millisecond
+}
+
+// Two running pods of test-service's second revision.
+func revisionPods() []api.Pod {
+	pods := make([]api.Pod, 2)
+	for i := range pods {
+		pods[i] = api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Name:   fmt.Sprintf("pod-%v", i),
+				Labels: map[string]string{"service-name": "test-service", revisionLabel: "2"},
+			},
+			Status: api.PodStatus{Phase: api.PodRunning},
+		}
+	}
+	return pods
+}
+
+func TestRecordRevision(t *testing.T) {
+	servicesSetup()
+	rc, _ := replicationControllerSpecFromService(*services[0])
+	for i := 0; i < maxRevisions+2; i++ {
+		assert.NoError(t, recordRevision(&rc))
+	}
+
+	history, err := revisionsFromReplicationController(rc)
+	assert.NoError(t, err)
+	if assert.Len(t, history, maxRevisions) {
+		assert.Equal(t, 3, history[0].Revision)
+		assert.Equal(t, maxRevisions+2, history[maxRevisions-1].Revision)
+		assert.Equal(t, rc.ObjectMeta.Annotations[definitionAnnotation], history[0].Definition)
+	}
+	assert.Equal(t, "12", rc.Spec.Template.ObjectMeta.Labels[revisionLabel])
+}
+
+func TestCreateServicesRecordsRevision(t *testing.T) {
+	servicesSetup()
+	_, err := adapter.CreateServices(services)
+
+	assert.NoError(t, err)
+	history, err := revisionsFromReplicationController(te.CreatedSpec)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, 1, history[0].Revision)
+		assert.Equal(t, "test-app", history[0].Template.ObjectMeta.Labels[applicationLabel])
+	}
+	assert.Equal(t, "1", te.CreatedSpec.Spec.Template.ObjectMeta.Labels[revisionLabel])
+}
+
+func TestSuccessfulGetServiceRevisions(t *testing.T) {
+	setupRevisions()
+	revisions, err := adapter.GetServiceRevisions("test-service")
+
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, 1, revisions[0].Revision)
+		assert.Equal(t, "redis:latest", revisions[0].Image)
+		assert.False(t, revisions[0].Current)
+		assert.Equal(t, "redis:2.8", revisions[1].Image)
+		assert.True(t, revisions[1].Current)
+	}
+}
+
+func TestNoHistoryGetServiceRevisions(t *testing.T) {
+	setupRCs()
+	revisions, err := adapter.GetServiceRevisions("test-service")
+
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestSuccessfulRollbackService(t *testing.T) {
+	setupRevisions()
+	sd, err := adapter.RollbackService("test-service", 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", sd.ID)
+	if assert.Len(t, te.UpdatedRCs, 1) {
+		template := te.UpdatedRCs[0].Spec.Template
+		assert.Equal(t, "redis:latest", template.Spec.Containers[0].Image)
+		assert.Equal(t, "3", template.ObjectMeta.Labels[revisionLabel])
+	}
+	assert.Equal(t, []string{"pod-0", "pod-1"}, te.DeletedPods)
+	for _, p := range te.Pods {
+		assert.Equal(t, "3", p.ObjectMeta.Labels[revisionLabel])
+	}
+
+	revisions, _ := adapter.GetServiceRevisions("test-service")
+	if assert.Len(t, revisions, 3) {
+		assert.Equal(t, 3, revisions[2].Revision)
+		assert.Equal(t, "redis:latest", revisions[2].Image)
+	}
+}
+
+func TestErroredTimeoutRollbackService(t *testing.T) {
+	setupRevisions()
+	te.ReplacePods = false
+	_, err := adapter.RollbackService("test-service", 1)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusGatewayTimeout, pmxErr.Code)
+		assert.Contains(t, pmxErr.Message, "with 0 of 2 pods replaced")
+	}
+	assert.Equal(t, []string{"pod-0"}, te.DeletedPods)
+}
+
+func TestErroredRollbackService(t *testing.T) {
+	for revision, code := range map[int]int{
+		5: http.StatusNotFound,
+		2: http.StatusConflict,
+	} {
+		setupRevisions()
+		_, err := adapter.RollbackService("test-service", revision)
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr) && assert.True(t, ok) {
+			assert.Equal(t, code, pmxErr.Code)
+		}
+		assert.Empty(t, te.UpdatedRCs)
+	}
+}
+
+func TestErroredPolicyRollbackService(t *testing.T) {
+	setupRevisions()
+	policy.AllowedRegistries = []string{"registry.example.com"}
+	_, err := adapter.RollbackService("test-service", 1)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+	assert.Empty(t, te.DeletedPods)
+}
+
+func TestSuccessfulStartRollbackService(t *testing.T) {
+	setupRevisions()
+	newOperationID = func() string { return "test-operation" }
+	started, err := adapter.StartRollbackService("test-service", 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, RollbackOperation, started.Kind)
+	assert.Len(t, te.UpdatedRCs, 1)
+	op := waitForOperation(t, started.ID)
+	assert.Equal(t, OperationSucceeded, op.State)
+	assert.Equal(t, ServiceRolledBack, op.Services[0].State)
+	assert.Equal(t, []string{"pod-0", "pod-1"}, te.DeletedPods)
+	if assert.Len(t, op.Result, 1) {
+		assert.Equal(t, "test-service", op.Result[0].ID)
+	}
+}
+
+func TestErroredTimeoutStartRollbackService(t *testing.T) {
+	setupRevisions()
+	te.ReplacePods = false
+	started, _ := adapter.StartRollbackService("test-service", 1)
+	op := waitForOperation(t, started.ID)
+
+	assert.Equal(t, OperationFailed, op.State)
+	assert.Equal(t, http.StatusGatewayTimeout, op.Code)
+	assert.Equal(t, ServiceFailed, op.Services[0].State)
+}
+
+func TestErroredStartRollbackService(t *testing.T) {
+	setupRevisions()
+	_, err := adapter.StartRollbackService("test-service", 2)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, adapter.GetOperations())
+}
+
+func TestSuccessfulRollbackServiceHandler(t *testing.T) {
+	setupRevisions()
+	newOperationID = func() string { return "test-operation" }
+	r, _ := http.NewRequest("POST", "http://localhost", strings.NewReader(`{"revision": 1}`))
+	w := httptest.NewRecorder()
+	code, _ := rollbackService(adapter, map[string]string{"id": "test-service"}, r, w)
+
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "/v1/operations/test-operation", w.Header().Get("Location"))
+	waitForOperation(t, "test-operation")
+	assert.Len(t, te.UpdatedRCs, 1)
+}
+
+func TestErroredMissingRevisionRollbackServiceHandler(t *testing.T) {
+	setupRevisions()
+	r, _ := http.NewRequest("POST", "http://localhost", strings.NewReader(`{}`))
+	code, body := rollbackService(adapter, map[string]string{"id": "test-service"}, r, httptest.NewRecorder())
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "revision is required", body)
+}
@@ -0,0 +1,84 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+)
+
+// How long a replacement pod has to become ready before a rollout gives up.
+var replacementTimeout = 2 * time.Minute
+
+// replacePods swaps a ReplicationController's pods for ones from its current
+// template, one at a time. Deleting a pod has the ReplicationController start
+// another from the template, and the next pod isn't touched until that one is
+// ready, so the service never loses more than one replica.
+func replacePods(rc api.ReplicationController) error {
+	id := rc.ObjectMeta.Name
+	revision := rc.Spec.Template.ObjectMeta.Labels[revisionLabel]
+	selector := labels.SelectorFromSet(labels.Set(rc.Spec.Selector))
+	pods, err := DefaultExecutor.GetPods(selector)
+	if err != nil {
+		return err
+	}
+
+	current, replaced := 0, 0
+	for _, p := range pods {
+		if p.ObjectMeta.Labels[revisionLabel] == revision && isPodReady(p) {
+			current++
+		}
+	}
+
+	for _, p := range pods {
+		if p.ObjectMeta.Labels[revisionLabel] == revision {
+			continue
+		}
+
+		if err := DefaultExecutor.DeletePod(p.ObjectMeta.Name); err != nil {
+			return err
+		}
+		replaced++
+
+		err := wait.Poll(pollInterval, replacementTimeout, func() (bool, error) {
+			pods, err := DefaultExecutor.GetPods(selector)
+			if err != nil {
+				return false, err
+			}
+
+			ready := 0
+			for _, p := range pods {
+				if p.ObjectMeta.Labels[revisionLabel] == revision && isPodReady(p) {
+					ready++
+				}
+			}
+			return ready >= current+replaced, nil
+		})
+		if err == wait.ErrWaitTimeout {
+			msg := fmt.Sprintf("timed out waiting for a replacement pod of '%v' to be ready, with %v of %v pods replaced", id, replaced-1, len(pods))
+			return pmxadapter.NewError(http.StatusGatewayTimeout, msg)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// A running pod is ready unless its readiness probe says otherwise.
+func isPodReady(p api.Pod) bool {
+	if p.Status.Phase != api.PodRunning {
+		return false
+	}
+
+	for _, c := range p.Status.Conditions {
+		if c.Type == api.PodReady {
+			return c.Status == api.ConditionFull
+		}
+	}
+	return true
+}
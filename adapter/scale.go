@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+)
+
+const maxReplicas = 100
+
+var scaleTimeout = 2 * time.Minute
+
+// ScaleService changes the number of replicas a service runs. When wait is
+// set it doesn't return until the ReplicationController reports that many
+// replicas, or until scaleTimeout passes.
+func (a KubernetesAdapter) ScaleService(id string, replicas int, wait bool) (pmxadapter.ServiceDeployment, error) {
+	if replicas < 0 || replicas > maxReplicas {
+		msg := fmt.Sprintf("replicas must be between 0 and %v", maxReplicas)
+		return pmxadapter.ServiceDeployment{}, pmxadapter.NewError(http.StatusBadRequest, msg)
+	}
+	if err := checkServiceReplicas(replicas); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	// Scaling would leave a paused service with two replica counts to come
+	// back to.
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if isPaused(rc) {
+		msg := fmt.Sprintf("service '%v' is paused, resume it first", id)
+		return pmxadapter.ServiceDeployment{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+	if err := checkApplicationReplicas(rc, replicas-rc.Spec.Replicas); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	rc, err = DefaultExecutor.ScaleReplicationController(id, replicas)
+	if err != nil {
+		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonNotFound {
+			return pmxadapter.ServiceDeployment{}, pmxadapter.NewNotFoundError(err.Error())
+		}
+
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	if wait {
+		rc, err = waitForReplicas(id, replicas)
+		if err != nil {
+			return pmxadapter.ServiceDeployment{}, err
+		}
+	}
+
+	status, err := statusFromReplicationController(rc)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	sd := pmxadapter.ServiceDeployment{
+		ID:          rc.ObjectMeta.Name,
+		ActualState: status,
+	}
+	return sd, nil
+}
+
+func waitForReplicas(id string, replicas int) (api.ReplicationController, error) {
+	var rc api.ReplicationController
+	err := wait.Poll(pollInterval, scaleTimeout, func() (bool, error) {
+		var err error
+		rc, err = DefaultExecutor.GetReplicationController(id)
+		if err != nil {
+			return false, err
+		}
+
+		return rc.Status.Replicas == replicas, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		msg := fmt.Sprintf("timed out waiting for '%v' to reach %v replicas", id, replicas)
+		return api.ReplicationController{}, pmxadapter.NewError(http.StatusGatewayTimeout, msg)
+	}
+
+	return rc, err
+}
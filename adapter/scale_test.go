@@ -0,0 +1,104 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func scaleSetup() {
+	setupRCs()
+	pollInterval = time.Millisecond
+	scaleTimeout = 20 * time.Millisecond
+}
+
+func TestSuccessfulScaleService(t *testing.T) {
+	scaleSetup()
+	sd, err := adapter.ScaleService("test-service", 3, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-service", te.ScaledID)
+	assert.Equal(t, 3, te.ScaledReplicas)
+	assert.Equal(t, pmxadapter.ServiceDeployment{ID: "test-service", ActualState: "pending"}, sd)
+}
+
+func TestSuccessfulWaitingScaleService(t *testing.T) {
+	scaleSetup()
+	te.ScaleConverges = true
+	sd, err := adapter.ScaleService("test-service", 2, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "running 0/2", sd.ActualState)
+}
+
+func TestErroredTimedOutScaleService(t *testing.T) {
+	scaleSetup()
+	sd, err := adapter.ScaleService("test-service", 2, true)
+
+	assert.Equal(t, pmxadapter.ServiceDeployment{}, sd)
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusGatewayTimeout, pmxErr.Code)
+		assert.Equal(t, "timed out waiting for 'test-service' to reach 2 replicas", pmxErr.Message)
+	}
+}
+
+func TestErroredOutOfBoundsScaleService(t *testing.T) {
+	scaleSetup()
+	for _, replicas := range []int{-1, maxReplicas + 1} {
+		_, err := adapter.ScaleService("test-service", replicas, false)
+
+		pmxErr, ok := err.(*pmxadapter.Error)
+		if assert.Error(t, pmxErr) && assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		}
+	}
+	assert.Empty(t, te.ScaledID)
+}
+
+func TestErroredNotFoundScaleService(t *testing.T) {
+	scaleSetup()
+	te.ScaleError = kerrors.NewNotFound("thing", "name")
+	_, err := adapter.ScaleService("test-service", 1, false)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, pmxErr.Code)
+	}
+}
+
+func TestErroredScaleService(t *testing.T) {
+	scaleSetup()
+	te.ScaleError = errors.New("test error")
+	_, err := adapter.ScaleService("test-service", 1, false)
+
+	assert.EqualError(t, err, "test error")
+}
+
+func TestErroredApplicationPolicyScaleService(t *testing.T) {
+	scaleSetup()
+	policy.MaxReplicasPerApplication = 6
+	te.RCs[0].ObjectMeta.Labels = map[string]string{applicationLabel: "test-app"}
+	other := api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: "other", Labels: map[string]string{applicationLabel: "test-app"}},
+		Spec:       api.ReplicationControllerSpec{Replicas: 4},
+	}
+	te.RCs = append(te.RCs, other)
+
+	_, err := adapter.ScaleService("test-service", 3, false)
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusForbidden, pmxErr.Code)
+		assert.Equal(t, "application would have 7 replicas, more than the 6 policy allows", pmxErr.Message)
+	}
+	assert.Empty(t, te.ScaledID)
+
+	_, err = adapter.ScaleService("test-service", 2, false)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,136 @@
+package adapter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+const (
+	secretsVariable   = extensionPrefix + "SECRETS"
+	secretsVolumeName = "panamax-secrets"
+	secretsMountPath  = "/etc/panamax/secrets"
+	// Stands in for secret values in the definition stored on the
+	// ReplicationController, which anyone who can read RCs can see.
+	redactedValue = "[secret]"
+)
+
+// secretPatterns are shell patterns, like "*_PASSWORD", for variable names
+// that are always secret. They're configured as a comma-separated
+// SECRET_VARIABLES.
+var secretPatterns []string
+
+// isSecretVariable reports whether a variable's value belongs in the service's
+// Secret rather than its pod template. Templates name their own secrets in a
+// comma-separated PANAMAX_SECRETS, on top of the adapter's patterns.
+func isSecretVariable(s pmxadapter.Service, name string) bool {
+	if isExtensionVariable(name) {
+		return false
+	}
+
+	if names, exists := extensionValue(s, secretsVariable); exists {
+		for _, n := range strings.Split(names, ",") {
+			if strings.TrimSpace(n) == name {
+				return true
+			}
+		}
+	}
+
+	for _, p := range secretPatterns {
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretFromService collects a service's secret variables into a Secret, or
+// returns nil when it has none. Each value becomes a file in the secrets
+// volume, named after its variable.
+func secretFromService(s pmxadapter.Service) (*api.Secret, error) {
+	data := map[string][]byte{}
+	names := map[string]string{}
+	size := 0
+	for _, e := range s.Environment {
+		if !isSecretVariable(s, e.Variable) {
+			continue
+		}
+
+		if fileVariable := secretFileVariable(e.Variable).Name; isPlainVariable(s, fileVariable) {
+			return nil, newExtensionError(fmt.Sprintf("secret variable '%v' is passed to the container as '%v', which is already defined", e.Variable, fileVariable))
+		}
+
+		key := secretKey(e.Variable)
+		if key == "" {
+			return nil, newExtensionError(fmt.Sprintf("secret variable '%v' has no usable file name", e.Variable))
+		}
+		if other, exists := names[key]; exists && other != e.Variable {
+			return nil, newExtensionError(fmt.Sprintf("secret variables '%v' and '%v' would share the file '%v'", other, e.Variable, key))
+		}
+		names[key] = e.Variable
+		size += len(e.Value) - len(data[key])
+		data[key] = []byte(e.Value)
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if size > api.MaxSecretSize {
+		return nil, newExtensionError(fmt.Sprintf("secret variables for service '%v' are larger than %v bytes", s.Name, api.MaxSecretSize))
+	}
+
+	safeName := sanitizeServiceName(s.Name)
+	return &api.Secret{
+		ObjectMeta: api.ObjectMeta{
+			Name:   secretName(safeName),
+			Labels: map[string]string{"service-name": safeName},
+		},
+		Data: data,
+		Type: api.SecretTypeOpaque,
+	}, nil
+}
+
+// isPlainVariable reports whether a service defines a variable that goes into
+// the container's environment as is.
+func isPlainVariable(s pmxadapter.Service, name string) bool {
+	_, exists := extensionValue(s, name)
+	return exists && !isExtensionVariable(name) && !isSecretVariable(s, name)
+}
+
+func secretName(safeName string) string {
+	return safeName + "-secrets"
+}
+
+// Secret keys have to be DNS subdomains, which variable names generally
+// aren't.
+func secretKey(variable string) string {
+	return strings.Trim(sanitizeServiceName(variable), "-")
+}
+
+// secretFileVariable points a container at the file holding a secret, using
+// the *_FILE convention that many images' entrypoints already understand.
+func secretFileVariable(variable string) api.EnvVar {
+	return api.EnvVar{
+		Name:  variable + "_FILE",
+		Value: path.Join(secretsMountPath, secretKey(variable)),
+	}
+}
+
+// redactSecrets copies a service, hiding the values of its secret variables.
+func redactSecrets(s pmxadapter.Service) pmxadapter.Service {
+	env := make([]*pmxadapter.Environment, len(s.Environment))
+	for i, e := range s.Environment {
+		if isSecretVariable(s, e.Variable) {
+			env[i] = &pmxadapter.Environment{Variable: e.Variable, Value: redactedValue}
+		} else {
+			env[i] = e
+		}
+	}
+
+	s.Environment = env
+	return s
+}
@@ -0,0 +1,144 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSecrets() {
+	servicesSetup()
+	secretPatterns = []string{"*_PASSWORD"}
+	s := withEnvironment(*services[0],
+		"DB_PASSWORD", "hunter2",
+		"API_TOKEN", "abc123",
+		"PANAMAX_SECRETS", "API_TOKEN, OTHER",
+	)
+	services[0] = &s
+}
+
+func TestIsSecretVariable(t *testing.T) {
+	setupSecrets()
+	s := *services[0]
+
+	assert.True(t, isSecretVariable(s, "DB_PASSWORD"))
+	assert.True(t, isSecretVariable(s, "API_TOKEN"))
+	assert.False(t, isSecretVariable(s, "VAR_NAME"))
+	assert.False(t, isSecretVariable(s, "PANAMAX_SECRETS"))
+}
+
+func TestSecretFromService(t *testing.T) {
+	setupSecrets()
+	secret, err := secretFromService(*services[0])
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, secret) {
+		assert.Equal(t, "test-service-secrets", secret.ObjectMeta.Name)
+		assert.Equal(t, "test-service", secret.ObjectMeta.Labels["service-name"])
+		assert.Equal(t, map[string][]byte{
+			"db-password": []byte("hunter2"),
+			"api-token":   []byte("abc123"),
+		}, secret.Data)
+	}
+}
+
+func TestNoSecretsSecretFromService(t *testing.T) {
+	servicesSetup()
+	secret, err := secretFromService(*services[0])
+
+	assert.NoError(t, err)
+	assert.Nil(t, secret)
+}
+
+func TestErroredCollidingSecretFromService(t *testing.T) {
+	servicesSetup()
+	s := withEnvironment(*services[0],
+		"db_password", "one",
+		"DB_PASSWORD", "two",
+		"PANAMAX_SECRETS", "db_password,DB_PASSWORD",
+	)
+	_, err := secretFromService(s)
+
+	pmxErr, ok := err.(*pmxadapter.Error)
+	if assert.Error(t, pmxErr) && assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		assert.Contains(t, pmxErr.Message, "db-password")
+	}
+}
+
+func TestErroredDefinedFileVariableSecretFromService(t *testing.T) {
+	setupSecrets()
+	s := withEnvironment(*services[0], "DB_PASSWORD_FILE", "/run/db-password")
+	_, err := secretFromService(s)
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		pmxErr := err.(*pmxadapter.Error)
+		assert.Equal(t, http.StatusBadRequest, pmxErr.Code)
+		assert.Equal(t, "secret variable 'DB_PASSWORD' is passed to the container as 'DB_PASSWORD_FILE', which is already defined", pmxErr.Message)
+	}
+}
+
+func TestSecretsReplicationControllerFromService(t *testing.T) {
+	setupSecrets()
+	spec, err := replicationControllerSpecFromService(*services[0])
+	assert.NoError(t, err)
+
+	podSpec := spec.Spec.Template.Spec
+	if assert.Len(t, podSpec.Volumes, 1) && assert.NotNil(t, podSpec.Volumes[0].Source.Secret) {
+		assert.Equal(t, "test-service-secrets", podSpec.Volumes[0].Source.Secret.Target.Name)
+	}
+
+	c := podSpec.Containers[0]
+	if assert.Len(t, c.VolumeMounts, 1) {
+		assert.Equal(t, "/etc/panamax/secrets", c.VolumeMounts[0].MountPath)
+		assert.True(t, c.VolumeMounts[0].ReadOnly)
+	}
+	if assert.Len(t, c.Env, 3) {
+		assert.Equal(t, "VAR_NAME", c.Env[0].Name)
+		assert.Equal(t, "DB_PASSWORD_FILE", c.Env[1].Name)
+		assert.Equal(t, "/etc/panamax/secrets/db-password", c.Env[1].Value)
+		assert.Equal(t, "API_TOKEN_FILE", c.Env[2].Name)
+	}
+
+	definition, err := definitionFromReplicationController(spec)
+	assert.NoError(t, err)
+	for _, e := range definition.Environment {
+		assert.NotEqual(t, "hunter2", e.Value)
+		assert.NotEqual(t, "abc123", e.Value)
+	}
+	assert.Equal(t, "hunter2", services[0].Environment[1].Value)
+}
+
+func TestSecretsCreateServices(t *testing.T) {
+	setupSecrets()
+	_, err := adapter.CreateServices(services)
+
+	assert.NoError(t, err)
+	if assert.Len(t, te.Secrets, 1) {
+		assert.Equal(t, "test-service-secrets", te.Secrets[0].ObjectMeta.Name)
+		assert.Equal(t, "test-app", te.Secrets[0].ObjectMeta.Labels[applicationLabel])
+	}
+}
+
+func TestErroredSecretsCreateServices(t *testing.T) {
+	setupSecrets()
+	te.CreateSecretError = errors.New("test error")
+	sd, err := adapter.CreateServices(services)
+
+	assert.Len(t, sd, 0)
+	assert.EqualError(t, err, "test error")
+	assert.Empty(t, te.KServices)
+	assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+}
+
+func TestFailedCreateDeletesSecretCreateServices(t *testing.T) {
+	setupSecrets()
+	te.CreateRCError = errors.New("test error")
+	_, err := adapter.CreateServices(services)
+
+	assert.EqualError(t, err, "test error")
+	assert.Equal(t, []string{"test-service-secrets"}, te.DeletedSecrets)
+}
@@ -0,0 +1,93 @@
+package adapter
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"github.com/codegangsta/martini"
+)
+
+// ExtensionAddr is where the extension server listens. The standard Panamax
+// adapter API is served by pmxadapter on :8001 and can't be extended, so
+// Kubernetes-specific operations get a server of their own.
+const ExtensionAddr = ":8002"
+
+// NewExtensionServer creates a martini server for the operations that fall
+// outside of the standard Panamax adapter API. Routes mirror the standard
+// API's layout so that the two can sit behind the same prefix.
+func NewExtensionServer(a KubernetesAdapter) http.Handler {
+	s := martini.New()
+
+	s.Use(martini.Recovery())
+	s.Use(martini.Logger())
+	s.Use(authenticate)
+	s.Use(func(c martini.Context, w http.ResponseWriter) {
+		c.Map(a)
+		w.Header().Set("Content-Type", "application/json")
+	})
+
+	router := martini.NewRouter()
+	router.Group("/v1", func(r martini.Router) {
+		r.Post(`/services`, createServices)
+		r.Get(`/services/:id`, getServiceDetail)
+		r.Put(`/services/:id/scale`, scaleService)
+		r.Delete(`/services/:id`, deleteService)
+		r.Get(`/services/:id/logs`, getServiceLogs)
+		r.Get(`/services/:id/events`, getServiceEvents)
+		r.Get(`/services/:id/metrics`, getServiceMetrics)
+		r.Post(`/services/:id/exec`, requireCredentials, execInService)
+		r.Get(`/services/:id/revisions`, getServiceRevisions)
+		r.Post(`/services/:id/rollback`, rollbackService)
+		r.Post(`/services/:id/update`, startServiceUpdate)
+		r.Post(`/services/:id/update/promote`, promoteServiceUpdate)
+		r.Delete(`/services/:id/update`, abortServiceUpdate)
+		r.Post(`/services/:id/pause`, pauseService)
+		r.Post(`/services/:id/resume`, resumeService)
+		r.Get(`/applications`, getApplications)
+		r.Get(`/applications/:id`, getApplication)
+		r.Delete(`/applications/:id`, deleteApplication)
+		r.Post(`/applications/:id/pause`, pauseApplication)
+		r.Post(`/applications/:id/resume`, resumeApplication)
+		r.Get(`/operations`, getOperations)
+		r.Get(`/operations/:id`, getOperation)
+		r.Get(`/watch/services`, watchServices)
+		r.Get(`/orphans`, getOrphans)
+		r.Delete(`/orphans`, deleteOrphans)
+	})
+
+	s.Action(router.Handle)
+	return s
+}
+
+// Credentials are optional for the extension API as a whole, and only checked
+// once ADAPTER_USERNAME is set. pmxadapter's standard API doesn't check any.
+func authenticate(w http.ResponseWriter, r *http.Request) {
+	if apiUsername == "" {
+		return
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(username), []byte(apiUsername)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(apiPassword)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="panamax-kubernetes-adapter"`)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+	}
+}
+
+// Some operations are too dangerous to leave open, so they're refused
+// outright until credentials are configured.
+func requireCredentials(w http.ResponseWriter) {
+	if apiUsername == "" {
+		http.Error(w, "this operation requires ADAPTER_USERNAME and ADAPTER_PASSWORD to be set", http.StatusForbidden)
+	}
+}
+
+// StartExtensionServer serves NewExtensionServer on ExtensionAddr, and like
+// pmxadapter's server it gives up on the whole process if it can't listen.
+func StartExtensionServer(a KubernetesAdapter) {
+	if err := http.ListenAndServe(ExtensionAddr, NewExtensionServer(a)); err != nil {
+		log.Fatal(err)
+	}
+}
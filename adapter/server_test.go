@@ -0,0 +1,173 @@
+package adapter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(method string, path string, body string, username string, password string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+	if username != "" {
+		r.SetBasicAuth(username, password)
+	}
+	w := httptest.NewRecorder()
+	NewExtensionServer(adapter).ServeHTTP(w, r)
+	return w
+}
+
+// serveUpgraded sends stdin over an upgraded connection to the extension
+// server, and returns the response along with everything sent back after it.
+func serveUpgraded(t *testing.T, path string, stdin string) (*http.Response, []byte) {
+	server := httptest.NewServer(NewExtensionServer(adapter))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+
+	r, _ := http.NewRequest("POST", server.URL+path, nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "tcp")
+	r.SetBasicAuth("user", "secret")
+	r.Write(conn)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, r)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	io.WriteString(conn, stdin)
+	conn.(*net.TCPConn).CloseWrite()
+	out, _ := ioutil.ReadAll(br)
+	return resp, out
+}
+
+func frame(stream byte, data string) string {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return string(header) + data
+}
+
+func withCredentials(username string, password string, f func()) {
+	origUsername, origPassword := apiUsername, apiPassword
+	apiUsername, apiPassword = username, password
+	defer func() { apiUsername, apiPassword = origUsername, origPassword }()
+	f()
+}
+
+func TestSuccessfulUnauthenticatedExtensionServer(t *testing.T) {
+	setupRCs()
+	w := serve("GET", "/v1/services/test-service", "", "", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestSuccessfulAuthenticatedExtensionServer(t *testing.T) {
+	setupRCs()
+	withCredentials("user", "secret", func() {
+		w := serve("GET", "/v1/services/test-service", "", "user", "secret")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestErroredBadCredentialsExtensionServer(t *testing.T) {
+	setupRCs()
+	withCredentials("user", "secret", func() {
+		w := serve("GET", "/v1/services/test-service", "", "user", "wrong")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+		w = serve("GET", "/v1/services/test-service", "", "", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestSuccessfulExecExtensionServer(t *testing.T) {
+	setupExec()
+	withCredentials("user", "secret", func() {
+		w := serve("POST", "/v1/services/test-service/exec", `{"command": ["ls"]}`, "user", "secret")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"pod":"pod-b"`)
+	})
+}
+
+func TestSuccessfulInteractiveExecExtensionServer(t *testing.T) {
+	setupExec()
+	te.RunErrorOutput = "oops\n"
+	withCredentials("user", "secret", func() {
+		resp, out := serveUpgraded(t, "/v1/services/test-service/exec?command=sh&command=-c&command=cat", "typed\n")
+
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, "tcp", resp.Header.Get("Upgrade"))
+		assert.Equal(t, frame(1, "hello\n")+frame(2, "oops\n")+frame(1, "typed\n"), string(out))
+		assert.Equal(t, []string{"host-2 pod-b test-service sh -c cat"}, te.RanCommands)
+		assert.False(t, te.RanTTY)
+	})
+}
+
+func TestSuccessfulTTYInteractiveExecExtensionServer(t *testing.T) {
+	setupExec()
+	withCredentials("user", "secret", func() {
+		resp, out := serveUpgraded(t, "/v1/services/test-service/exec?command=sh&tty=true", "typed\n")
+
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, "hello\ntyped\n", string(out))
+		assert.True(t, te.RanTTY)
+	})
+}
+
+func TestErroredFailedInteractiveExecExtensionServer(t *testing.T) {
+	setupExec()
+	te.RunError = errors.New("test error")
+	withCredentials("user", "secret", func() {
+		resp, out := serveUpgraded(t, "/v1/services/test-service/exec?command=ls", "")
+
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, frame(1, "hello\n")+frame(2, "test error\n"), string(out))
+	})
+}
+
+func TestErroredNoRunningPodsInteractiveExecExtensionServer(t *testing.T) {
+	setupExec()
+	withCredentials("user", "secret", func() {
+		resp, _ := serveUpgraded(t, "/v1/services/test-service/exec?command=ls&pod=pod-a", "")
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assert.Empty(t, te.RanCommands)
+	})
+}
+
+func TestErroredUnconfiguredInteractiveExecExtensionServer(t *testing.T) {
+	setupExec()
+	resp, _ := serveUpgraded(t, "/v1/services/test-service/exec?command=ls", "")
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, te.RanCommands)
+}
+
+func TestErroredUnconfiguredExecExtensionServer(t *testing.T) {
+	setupExec()
+	w := serve("POST", "/v1/services/test-service/exec", `{"command": ["ls"]}`, "", "")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, te.RanCommands)
+}
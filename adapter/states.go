@@ -0,0 +1,392 @@
+package adapter
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+const (
+	StateAdded    = "added"
+	StateModified = "modified"
+	StateDeleted  = "deleted"
+	// A sync event gives a service's current state to a stream that can't
+	// be told what changed since its cursor.
+	StateSync = "sync"
+
+	// How many changes are kept for streams resuming from a cursor.
+	stateHistorySize = 1000
+	// A stream that falls this far behind is ended, and can resume from the
+	// last cursor it saw.
+	stateBufferSize = 100
+)
+
+// A ServiceStateEvent is a change in the state GetServices would report for a
+// service. Cursor is where to resume a stream from to hear about everything
+// after this event.
+type ServiceStateEvent struct {
+	Cursor        string `json:"cursor"`
+	Type          string `json:"type"`
+	Service       string `json:"service"`
+	State         string `json:"state,omitempty"`
+	PreviousState string `json:"previousState,omitempty"`
+	seq           uint64
+}
+
+// A StateSubscription is a stream of service state changes. Initial is what
+// the stream missed since its cursor, or every service's current state when
+// that isn't known. Events is closed if the stream falls too far behind.
+type StateSubscription struct {
+	Initial []ServiceStateEvent
+	Events  <-chan ServiceStateEvent
+	events  chan ServiceStateEvent
+}
+
+// Close ends the stream.
+func (s *StateSubscription) Close() {
+	states.unsubscribe(s.events)
+}
+
+// WatchServiceStates streams changes to the state of every service, resuming
+// from cursor if it's given. Every stream shares the same watches on the
+// apiserver, which are started with the first stream and stopped with the
+// last.
+func (a KubernetesAdapter) WatchServiceStates(cursor string) (*StateSubscription, error) {
+	return states.subscribe(cursor)
+}
+
+var states = newStateHub()
+
+// Cursors are only meaningful to the process that gave them out, so they
+// carry an epoch that's new each time it starts.
+type stateHub struct {
+	sync.Mutex
+	epoch       string
+	seq         uint64
+	current     map[string]string
+	history     []ServiceStateEvent
+	subscribers map[chan ServiceStateEvent]bool
+	stop        chan struct{}
+}
+
+func newStateHub() *stateHub {
+	return &stateHub{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		current:     map[string]string{},
+		subscribers: map[chan ServiceStateEvent]bool{},
+	}
+}
+
+func (h *stateHub) subscribe(cursor string) (*StateSubscription, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.stop == nil {
+		w, err := openStateWatches()
+		if err != nil {
+			return nil, err
+		}
+		h.resync(w)
+		h.stop = make(chan struct{})
+		go h.run(w, h.stop)
+	}
+
+	events := make(chan ServiceStateEvent, stateBufferSize)
+	h.subscribers[events] = true
+	return &StateSubscription{Initial: h.since(cursor), Events: events, events: events}, nil
+}
+
+func (h *stateHub) unsubscribe(events chan ServiceStateEvent) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.subscribers[events] {
+		delete(h.subscribers, events)
+		close(events)
+	}
+	if len(h.subscribers) == 0 && h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// The changes after cursor, when they're all still in the history, or else
+// every service's current state.
+func (h *stateHub) since(cursor string) []ServiceStateEvent {
+	parts := strings.SplitN(cursor, ".", 2)
+	if len(parts) == 2 && parts[0] == h.epoch {
+		seq, err := strconv.ParseUint(parts[1], 10, 64)
+		if err == nil && seq <= h.seq && (seq == h.seq || len(h.history) > 0 && seq+1 >= h.history[0].seq) {
+			missed := make([]ServiceStateEvent, 0)
+			for _, e := range h.history {
+				if e.seq > seq {
+					missed = append(missed, e)
+				}
+			}
+			return missed
+		}
+	}
+
+	names := make([]string, 0, len(h.current))
+	for name := range h.current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	snapshot := make([]ServiceStateEvent, len(names))
+	for i, name := range names {
+		snapshot[i] = ServiceStateEvent{Cursor: h.cursor(), Type: StateSync, Service: name, State: h.current[name], seq: h.seq}
+	}
+	return snapshot
+}
+
+func (h *stateHub) cursor() string {
+	return fmt.Sprintf("%v.%v", h.epoch, h.seq)
+}
+
+// Called with the lock held. A state of "" means the service is gone.
+func (h *stateHub) set(service string, state string) {
+	previous, existed := h.current[service]
+	e := ServiceStateEvent{Service: service, State: state, PreviousState: previous}
+	switch {
+	case state == "" && existed:
+		delete(h.current, service)
+		e.Type = StateDeleted
+	case state == "":
+		return
+	case !existed:
+		h.current[service] = state
+		e.Type = StateAdded
+	case previous != state:
+		h.current[service] = state
+		e.Type = StateModified
+	default:
+		return
+	}
+
+	h.seq++
+	e.seq = h.seq
+	e.Cursor = h.cursor()
+	h.history = append(h.history, e)
+	if len(h.history) > stateHistorySize {
+		h.history = h.history[len(h.history)-stateHistorySize:]
+	}
+
+	for events := range h.subscribers {
+		select {
+		case events <- e:
+		default:
+			delete(h.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Called with the lock held, to bring every service's state up to date with
+// freshly listed objects.
+func (h *stateHub) resync(w *stateWatches) {
+	for service := range h.current {
+		if _, exists := w.rcs[service]; !exists {
+			h.set(service, "")
+		}
+	}
+
+	names := make([]string, 0, len(w.rcs))
+	for name := range w.rcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.set(name, w.state(name))
+	}
+}
+
+// run keeps the states up to date from the watches until stop is closed.
+// When the watches end, everything is listed again and new ones started.
+func (h *stateHub) run(w *stateWatches, stop chan struct{}) {
+	defer func() { w.stopWatching() }()
+
+	for {
+		var service string
+		select {
+		case <-stop:
+			return
+		case e, ok := <-w.rcWatch.ResultChan():
+			if !ok || e.Type == watch.Error {
+				if w = h.rewatch(w, stop); w == nil {
+					return
+				}
+				continue
+			}
+			service = w.applyRC(e)
+		case e, ok := <-w.podWatch.ResultChan():
+			if !ok || e.Type == watch.Error {
+				if w = h.rewatch(w, stop); w == nil {
+					return
+				}
+				continue
+			}
+			service = w.applyPod(e)
+		}
+
+		if service == "" {
+			continue
+		}
+		h.Lock()
+		if h.stop == stop {
+			h.set(service, w.state(service))
+		}
+		h.Unlock()
+	}
+}
+
+// Retries every pollInterval until the watches are back, or returns nil once
+// stop is closed.
+func (h *stateHub) rewatch(old *stateWatches, stop chan struct{}) *stateWatches {
+	old.stopWatching()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(pollInterval):
+		}
+
+		w, err := openStateWatches()
+		if err != nil {
+			log.Printf("Watching service states failed: %v", err)
+			continue
+		}
+
+		h.Lock()
+		if h.stop == stop {
+			h.resync(w)
+		}
+		h.Unlock()
+		return w
+	}
+}
+
+// stateWatches are the watches on every Panamax ReplicationController and
+// pod, along with the objects they've told of so far.
+type stateWatches struct {
+	rcWatch  watch.Interface
+	podWatch watch.Interface
+	rcs      map[string]api.ReplicationController
+	pods     map[string]api.Pod
+}
+
+// The watches start before the listing, so that nothing can happen between
+// the two without the watches hearing about it.
+func openStateWatches() (*stateWatches, error) {
+	panamaxPods := labels.OneTermEqualSelector("panamax", "panamax")
+	rcWatch, err := DefaultExecutor.WatchReplicationControllers(labels.Everything(), "")
+	if err != nil {
+		return nil, err
+	}
+	podWatch, err := DefaultExecutor.WatchPods(panamaxPods, "")
+	if err != nil {
+		rcWatch.Stop()
+		return nil, err
+	}
+	w := &stateWatches{
+		rcWatch:  rcWatch,
+		podWatch: podWatch,
+		rcs:      map[string]api.ReplicationController{},
+		pods:     map[string]api.Pod{},
+	}
+
+	rcs, err := DefaultExecutor.GetReplicationControllers()
+	if err != nil {
+		w.stopWatching()
+		return nil, err
+	}
+	for _, rc := range rcs {
+		if isServiceReplicationController(rc) {
+			w.rcs[rc.ObjectMeta.Name] = rc
+		}
+	}
+	pods, err := DefaultExecutor.GetPods(panamaxPods)
+	if err != nil {
+		w.stopWatching()
+		return nil, err
+	}
+	for _, p := range pods {
+		w.pods[p.ObjectMeta.Name] = p
+	}
+
+	return w, nil
+}
+
+func (w *stateWatches) stopWatching() {
+	w.rcWatch.Stop()
+	w.podWatch.Stop()
+}
+
+// Returns the service whose state the event may have changed, if any.
+func (w *stateWatches) applyRC(e watch.Event) string {
+	rc, ok := e.Object.(*api.ReplicationController)
+	if !ok {
+		return ""
+	}
+
+	name := rc.ObjectMeta.Name
+	_, known := w.rcs[name]
+	if e.Type == watch.Deleted || !isServiceReplicationController(*rc) {
+		delete(w.rcs, name)
+		if !known {
+			return ""
+		}
+	} else {
+		w.rcs[name] = *rc
+	}
+	return name
+}
+
+func (w *stateWatches) applyPod(e watch.Event) string {
+	p, ok := e.Object.(*api.Pod)
+	if !ok {
+		return ""
+	}
+
+	if e.Type == watch.Deleted {
+		delete(w.pods, p.ObjectMeta.Name)
+	} else {
+		w.pods[p.ObjectMeta.Name] = *p
+	}
+	if _, exists := w.rcs[p.ObjectMeta.Labels["service-name"]]; !exists {
+		return ""
+	}
+	return p.ObjectMeta.Labels["service-name"]
+}
+
+// The service's state as statusFromReplicationController would give it, or ""
+// if the service is gone.
+func (w *stateWatches) state(service string) string {
+	rc, exists := w.rcs[service]
+	if !exists {
+		return ""
+	}
+
+	pods := make([]api.Pod, 0)
+	for _, p := range w.pods {
+		if p.ObjectMeta.Labels["service-name"] == service {
+			pods = append(pods, p)
+		}
+	}
+	return statusFromPods(rc, pods)
+}
+
+// The services whose states are streamed: ReplicationControllers that Panamax
+// created, leaving out updates in progress. Only Panamax's pods are watched,
+// so unlike GetServices, which reports every ReplicationController but the
+// next track, this leaves out ones created some other way.
+func isServiceReplicationController(rc api.ReplicationController) bool {
+	return isPanamaxReplicationController(rc) && !isNextTrack(rc)
+}
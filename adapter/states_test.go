@@ -0,0 +1,227 @@
+package adapter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStates() {
+	adapterSetup()
+	pollInterval = time.Millisecond
+	te.RCs = []api.ReplicationController{*stateRC("web", 2)}
+	te.Pods = []api.Pod{*statePod("web-1", api.PodRunning)}
+}
+
+func stateRC(name string, replicas int) *api.ReplicationController {
+	return &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Spec: api.ReplicationControllerSpec{
+			Replicas: replicas,
+			Template: &api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"panamax": "panamax", "service-name": name}},
+			},
+		},
+		Status: api.ReplicationControllerStatus{Replicas: replicas},
+	}
+}
+
+func statePod(name string, phase api.PodPhase) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"panamax": "panamax", "service-name": "web"},
+		},
+		Status: api.PodStatus{Phase: phase},
+	}
+}
+
+func nextStateEvent(t *testing.T, sub *StateSubscription) ServiceStateEvent {
+	select {
+	case e := <-sub.Events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no state event")
+		return ServiceStateEvent{}
+	}
+}
+
+func TestSuccessfulWatchServiceStates(t *testing.T) {
+	setupStates()
+	sub, err := adapter.WatchServiceStates("")
+	defer sub.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, te.RCWatches)
+	assert.Equal(t, 1, te.PodWatches)
+	assert.Equal(t, "panamax=panamax", te.GotPodsSelector.String())
+	if assert.Len(t, sub.Initial, 1) {
+		e := sub.Initial[0]
+		assert.Equal(t, StateSync, e.Type)
+		assert.Equal(t, "web", e.Service)
+		assert.Equal(t, "running 1/2", e.State)
+		assert.Equal(t, states.epoch+".1", e.Cursor)
+	}
+}
+
+func TestUnmanagedWatchServiceStates(t *testing.T) {
+	setupStates()
+	te.RCs[0].Spec.Template.ObjectMeta.Labels = map[string]string{}
+	te.RCs = append(te.RCs, *stateRC("web-next", 1))
+	te.RCs[1].ObjectMeta.Labels = map[string]string{trackLabel: nextTrack}
+	sub, err := adapter.WatchServiceStates("")
+	defer sub.Close()
+
+	assert.NoError(t, err)
+	assert.Empty(t, sub.Initial)
+}
+
+func TestPodChangeWatchServiceStates(t *testing.T) {
+	setupStates()
+	te.PodEvents = []watch.Event{
+		{Type: watch.Added, Object: statePod("web-2", api.PodPending)},
+		{Type: watch.Modified, Object: statePod("web-2", api.PodRunning)},
+	}
+	sub, _ := adapter.WatchServiceStates("")
+	defer sub.Close()
+	e := nextStateEvent(t, sub)
+
+	assert.Equal(t, StateModified, e.Type)
+	assert.Equal(t, "web", e.Service)
+	assert.Equal(t, "running 2/2", e.State)
+	assert.Equal(t, "running 1/2", e.PreviousState)
+	assert.Equal(t, states.epoch+".2", e.Cursor)
+}
+
+func TestRCChangeWatchServiceStates(t *testing.T) {
+	setupStates()
+	db := stateRC("db", 1)
+	db.Status.Replicas = 0
+	te.RCEvents = []watch.Event{
+		{Type: watch.Added, Object: db},
+		{Type: watch.Modified, Object: stateRC("web", 1)},
+		{Type: watch.Deleted, Object: db},
+	}
+	sub, _ := adapter.WatchServiceStates("")
+	defer sub.Close()
+
+	added := nextStateEvent(t, sub)
+	assert.Equal(t, StateAdded, added.Type)
+	assert.Equal(t, "db", added.Service)
+	assert.Equal(t, "pending", added.State)
+	modified := nextStateEvent(t, sub)
+	assert.Equal(t, "web", modified.Service)
+	assert.Equal(t, "running 1/1", modified.State)
+	deleted := nextStateEvent(t, sub)
+	assert.Equal(t, StateDeleted, deleted.Type)
+	assert.Equal(t, "db", deleted.Service)
+	assert.Equal(t, "pending", deleted.PreviousState)
+}
+
+func TestResumedWatchServiceStates(t *testing.T) {
+	setupStates()
+	te.PodEvents = []watch.Event{{Type: watch.Deleted, Object: statePod("web-1", api.PodRunning)}}
+	first, _ := adapter.WatchServiceStates("")
+	defer first.Close()
+	e := nextStateEvent(t, first)
+	second, err := adapter.WatchServiceStates(first.Initial[0].Cursor)
+	defer second.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ServiceStateEvent{e}, second.Initial)
+	assert.Equal(t, 1, te.RCWatches)
+}
+
+func TestUpToDateWatchServiceStates(t *testing.T) {
+	setupStates()
+	first, _ := adapter.WatchServiceStates("")
+	defer first.Close()
+	second, _ := adapter.WatchServiceStates(first.Initial[0].Cursor)
+	defer second.Close()
+
+	assert.Empty(t, second.Initial)
+}
+
+func TestUnknownCursorWatchServiceStates(t *testing.T) {
+	setupStates()
+	for _, cursor := range []string{"bad", "0.1", states.epoch + ".5"} {
+		sub, _ := adapter.WatchServiceStates(cursor)
+		if assert.Len(t, sub.Initial, 1, cursor) {
+			assert.Equal(t, StateSync, sub.Initial[0].Type)
+		}
+		sub.Close()
+	}
+}
+
+func TestClosedWatchServiceStates(t *testing.T) {
+	setupStates()
+	sub, _ := adapter.WatchServiceStates("")
+	sub.Close()
+
+	assert.Nil(t, states.stop)
+	_, open := <-sub.Events
+	assert.False(t, open)
+}
+
+func TestErroredWatchServiceStates(t *testing.T) {
+	setupStates()
+	te.WatchPodsError = errors.New("test error")
+	sub, err := adapter.WatchServiceStates("")
+
+	assert.Nil(t, sub)
+	assert.EqualError(t, err, "test error")
+	assert.Nil(t, states.stop)
+}
+
+type closingRecorder struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (r closingRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func TestSuccessfulWatchServices(t *testing.T) {
+	setupStates()
+	r, _ := http.NewRequest("GET", "/v1/watch/services", nil)
+	w := closingRecorder{httptest.NewRecorder(), make(chan bool, 1)}
+	w.closed <- true
+	watchServices(adapter, r, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "id: "+states.epoch+".1\nevent: sync\ndata: {")
+	assert.Contains(t, w.Body.String(), `"service":"web","state":"running 1/2"`)
+	assert.Nil(t, states.stop)
+}
+
+func TestResumedWatchServices(t *testing.T) {
+	setupStates()
+	sub, _ := adapter.WatchServiceStates("")
+	defer sub.Close()
+	r, _ := http.NewRequest("GET", "/v1/watch/services", nil)
+	r.Header.Set("Last-Event-ID", sub.Initial[0].Cursor)
+	w := closingRecorder{httptest.NewRecorder(), make(chan bool, 1)}
+	w.closed <- true
+	watchServices(adapter, r, w)
+
+	assert.NotContains(t, w.Body.String(), "event:")
+}
+
+func TestErroredWatchServices(t *testing.T) {
+	setupStates()
+	te.WatchRCsError = errors.New("test error")
+	r, _ := http.NewRequest("GET", "/v1/watch/services", nil)
+	w := httptest.NewRecorder()
+	watchServices(adapter, r, w)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "test error")
+}
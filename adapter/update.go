@@ -0,0 +1,384 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+)
+
+const (
+	CanaryStrategy    = "canary"
+	BlueGreenStrategy = "blueGreen"
+
+	updateAnnotation = "panamax.io/update"
+	// Pods of a service being updated are told apart by their track: the
+	// service's own ReplicationController runs the stable track, and the one
+	// standing up the new version runs the next.
+	trackLabel  = "panamax-track"
+	stableTrack = "stable"
+	nextTrack   = "next"
+)
+
+// UpdateOptions describe a change of image for a service. A canary runs
+// Replicas pods of the new image alongside the service's own, sharing its
+// traffic. Blue/green runs a full set of them, which only gets traffic once
+// the update is promoted.
+type UpdateOptions struct {
+	Strategy string `json:"strategy"`
+	Image    string `json:"image"`
+	Replicas int    `json:"replicas"`
+}
+
+// A ServiceUpdate is an update in progress, waiting to be promoted or
+// aborted.
+type ServiceUpdate struct {
+	Strategy  string    `json:"strategy"`
+	Image     string    `json:"image"`
+	Replicas  int       `json:"replicas"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// StartServiceUpdate stands up the new version of a service next to the
+// current one.
+func (a KubernetesAdapter) StartServiceUpdate(id string, opts UpdateOptions) (ServiceUpdate, error) {
+	if opts.Strategy != CanaryStrategy && opts.Strategy != BlueGreenStrategy {
+		msg := fmt.Sprintf("strategy must be '%v' or '%v'", CanaryStrategy, BlueGreenStrategy)
+		return ServiceUpdate{}, pmxadapter.NewError(http.StatusBadRequest, msg)
+	}
+	image, err := parseImage(opts.Image)
+	if err != nil {
+		return ServiceUpdate{}, err
+	}
+
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return ServiceUpdate{}, err
+	}
+	if _, exists := rc.ObjectMeta.Annotations[updateAnnotation]; exists {
+		msg := fmt.Sprintf("service '%v' is already being updated", id)
+		return ServiceUpdate{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+	if isPaused(rc) {
+		msg := fmt.Sprintf("service '%v' is paused, resume it first", id)
+		return ServiceUpdate{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+
+	update := ServiceUpdate{
+		Strategy:  opts.Strategy,
+		Image:     image.String(),
+		Replicas:  opts.Replicas,
+		StartedAt: time.Now().UTC(),
+	}
+	if update.Strategy == BlueGreenStrategy {
+		update.Replicas = rc.Spec.Replicas
+	} else if update.Replicas == 0 {
+		update.Replicas = 1
+	}
+	if update.Replicas < 1 || update.Replicas > maxReplicas {
+		msg := fmt.Sprintf("replicas must be between 1 and %v", maxReplicas)
+		return ServiceUpdate{}, pmxadapter.NewError(http.StatusBadRequest, msg)
+	}
+
+	next := nextReplicationController(rc, update)
+	if err := enforcePolicy([]api.ReplicationController{next}); err != nil {
+		return ServiceUpdate{}, err
+	}
+	if err := checkApplicationReplicas(rc, update.Replicas); err != nil {
+		return ServiceUpdate{}, err
+	}
+	if err := validateServicesImages([]*pmxadapter.Service{{Source: update.Image}}); err != nil {
+		return ServiceUpdate{}, err
+	}
+
+	if rc, err = pinToStableTrack(rc); err != nil {
+		return ServiceUpdate{}, err
+	}
+	// Blue/green keeps traffic on the stable track until it's promoted,
+	// while a canary takes its share straight away.
+	track := ""
+	if update.Strategy == BlueGreenStrategy {
+		track = stableTrack
+	}
+	if err := routeServiceTraffic(id, track); err != nil {
+		return ServiceUpdate{}, err
+	}
+
+	if _, err := DefaultExecutor.CreateReplicationController(next); err != nil {
+		if sErr, ok := err.(*errors.StatusError); ok && sErr.ErrStatus.Reason == api.StatusReasonAlreadyExists {
+			return ServiceUpdate{}, pmxadapter.NewAlreadyExistsError(err.Error())
+		}
+		return ServiceUpdate{}, err
+	}
+
+	encoded, err := json.Marshal(update)
+	if err != nil {
+		return ServiceUpdate{}, err
+	}
+	if rc.ObjectMeta.Annotations == nil {
+		rc.ObjectMeta.Annotations = map[string]string{}
+	}
+	rc.ObjectMeta.Annotations[updateAnnotation] = string(encoded)
+	if _, err := DefaultExecutor.UpdateReplicationController(rc); err != nil {
+		return ServiceUpdate{}, err
+	}
+
+	return update, nil
+}
+
+// PromoteServiceUpdate moves a service onto the new version: blue/green flips
+// traffic over to it, then the service's own pods are replaced with the new
+// image, one at a time, and the extra ReplicationController is removed.
+func (a KubernetesAdapter) PromoteServiceUpdate(id string) (pmxadapter.ServiceDeployment, error) {
+	rc, update, err := serviceUnderUpdate(id)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	if update.Strategy == BlueGreenStrategy {
+		if err := waitForNextTrack(id, update.Replicas); err != nil {
+			return pmxadapter.ServiceDeployment{}, err
+		}
+		if err := routeServiceTraffic(id, nextTrack); err != nil {
+			return pmxadapter.ServiceDeployment{}, err
+		}
+	}
+
+	template := copyPodTemplate(*rc.Spec.Template)
+	template.Spec.Containers[0].Image = update.Image
+	rc.Spec.Template = &template
+	if definition, err := definitionFromReplicationController(rc); err == nil && definition != nil {
+		definition.Source = update.Image
+		// As when it was first stored, this can't fail.
+		encoded, _ := json.Marshal(definition)
+		rc.ObjectMeta.Annotations[definitionAnnotation] = string(encoded)
+	}
+	delete(rc.ObjectMeta.Annotations, updateAnnotation)
+	if err := recordRevision(&rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if rc, err = DefaultExecutor.UpdateReplicationController(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	if err := replacePods(rc); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	if update.Strategy == BlueGreenStrategy {
+		if err := routeServiceTraffic(id, stableTrack); err != nil {
+			return pmxadapter.ServiceDeployment{}, err
+		}
+	}
+	if err := DefaultExecutor.DeleteReplicationController(nextName(id), false); err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+
+	status, err := statusFromReplicationController(rc)
+	if err != nil {
+		return pmxadapter.ServiceDeployment{}, err
+	}
+	sd := pmxadapter.ServiceDeployment{
+		ID:          rc.ObjectMeta.Name,
+		ActualState: status,
+	}
+	return sd, nil
+}
+
+// AbortServiceUpdate removes the new version, leaving the service as it was.
+// Blue/green never sent it traffic, and a canary's share goes back to the
+// service's own pods.
+func (a KubernetesAdapter) AbortServiceUpdate(id string) error {
+	rc, _, err := serviceUnderUpdate(id)
+	if err != nil {
+		return err
+	}
+
+	if err := DefaultExecutor.DeleteReplicationController(nextName(id), false); err != nil {
+		return err
+	}
+
+	delete(rc.ObjectMeta.Annotations, updateAnnotation)
+	_, err = DefaultExecutor.UpdateReplicationController(rc)
+	return err
+}
+
+func serviceUnderUpdate(id string) (api.ReplicationController, ServiceUpdate, error) {
+	rc, err := serviceReplicationController(id)
+	if err != nil {
+		return api.ReplicationController{}, ServiceUpdate{}, err
+	}
+
+	update, err := updateFromReplicationController(rc)
+	if err != nil {
+		return api.ReplicationController{}, ServiceUpdate{}, err
+	}
+	if update == nil {
+		msg := fmt.Sprintf("service '%v' isn't being updated", id)
+		return api.ReplicationController{}, ServiceUpdate{}, pmxadapter.NewError(http.StatusConflict, msg)
+	}
+
+	return rc, *update, nil
+}
+
+func updateFromReplicationController(rc api.ReplicationController) (*ServiceUpdate, error) {
+	encoded, exists := rc.ObjectMeta.Annotations[updateAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	var update ServiceUpdate
+	if err := json.Unmarshal([]byte(encoded), &update); err != nil {
+		return nil, fmt.Errorf("unreadable update for service '%v': %v", rc.ObjectMeta.Name, err)
+	}
+	return &update, nil
+}
+
+func nextName(id string) string {
+	return id + "-next"
+}
+
+// The next track's ReplicationController runs the service's pod template with
+// the new image, selecting only its own pods. It isn't labeled with the
+// application, as it's not a service in its own right.
+func nextReplicationController(rc api.ReplicationController, update ServiceUpdate) api.ReplicationController {
+	id := rc.ObjectMeta.Name
+	template := copyPodTemplate(*rc.Spec.Template)
+	template.ObjectMeta.Labels[trackLabel] = nextTrack
+	delete(template.ObjectMeta.Labels, revisionLabel)
+	template.Spec.Containers[0].Image = update.Image
+	// The service's own pods hold its host ports on their nodes, and the
+	// update's pods don't need them: KServices route to them by selector.
+	for i := range template.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		ports := make([]api.Port, len(c.Ports))
+		copy(ports, c.Ports)
+		for j := range ports {
+			ports[j].HostPort = 0
+		}
+		c.Ports = ports
+	}
+
+	return api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{
+			Name:   nextName(id),
+			Labels: map[string]string{"service-name": id, trackLabel: nextTrack},
+		},
+		Spec: api.ReplicationControllerSpec{
+			Replicas: update.Replicas,
+			Selector: map[string]string{"service-name": id, trackLabel: nextTrack},
+			Template: &template,
+		},
+	}
+}
+
+// The service's ReplicationController selects every pod with its name, which
+// would include the next track's. So before there's a next track, its pods are
+// labeled as the stable track and its selector narrowed to match. Pods are
+// labeled first, so that none of them stop matching along the way.
+func pinToStableTrack(rc api.ReplicationController) (api.ReplicationController, error) {
+	if rc.Spec.Selector[trackLabel] == stableTrack {
+		return rc, nil
+	}
+
+	template := copyPodTemplate(*rc.Spec.Template)
+	template.ObjectMeta.Labels[trackLabel] = stableTrack
+	rc.Spec.Template = &template
+	rc, err := DefaultExecutor.UpdateReplicationController(rc)
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+
+	pods, err := DefaultExecutor.GetPods(labels.SelectorFromSet(labels.Set(rc.Spec.Selector)))
+	if err != nil {
+		return api.ReplicationController{}, err
+	}
+	for _, p := range pods {
+		if p.ObjectMeta.Labels[trackLabel] == stableTrack {
+			continue
+		}
+		p.ObjectMeta.Labels[trackLabel] = stableTrack
+		if err := DefaultExecutor.UpdatePod(p); err != nil {
+			return api.ReplicationController{}, err
+		}
+	}
+
+	selector := map[string]string{trackLabel: stableTrack}
+	for k, v := range rc.Spec.Selector {
+		selector[k] = v
+	}
+	rc.Spec.Selector = selector
+	return DefaultExecutor.UpdateReplicationController(rc)
+}
+
+// routeServiceTraffic points the KServices for a service at one track's pods,
+// or with no track, at all of them. Each KService changes in a single update,
+// so there's no moment where it routes to neither.
+func routeServiceTraffic(id string, track string) error {
+	kServices, err := DefaultExecutor.GetKServices(labels.OneTermEqualSelector("service-name", id))
+	if err != nil {
+		return err
+	}
+
+	for _, ks := range kServices {
+		selector := map[string]string{"panamax": "panamax", "service-name": id}
+		if track != "" {
+			selector[trackLabel] = track
+		}
+		ks.Spec.Selector = selector
+		if err := DefaultExecutor.UpdateKService(ks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func waitForNextTrack(id string, replicas int) error {
+	selector := labels.SelectorFromSet(labels.Set{"service-name": id, trackLabel: nextTrack})
+	err := wait.Poll(pollInterval, replacementTimeout, func() (bool, error) {
+		pods, err := DefaultExecutor.GetPods(selector)
+		if err != nil {
+			return false, err
+		}
+
+		ready := 0
+		for _, p := range pods {
+			if isPodReady(p) {
+				ready++
+			}
+		}
+		return ready >= replicas, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		msg := fmt.Sprintf("timed out waiting for %v new pods of '%v' to be ready", replicas, id)
+		return pmxadapter.NewError(http.StatusGatewayTimeout, msg)
+	}
+	return err
+}
+
+// Templates hold maps and slices, which mustn't be shared between the
+// ReplicationControllers built from them.
+func copyPodTemplate(t api.PodTemplateSpec) api.PodTemplateSpec {
+	labels := make(map[string]string, len(t.ObjectMeta.Labels))
+	for k, v := range t.ObjectMeta.Labels {
+		labels[k] = v
+	}
+	t.ObjectMeta.Labels = labels
+
+	containers := make([]api.Container, len(t.Spec.Containers))
+	copy(containers, t.Spec.Containers)
+	t.Spec.Containers = containers
+
+	return t
+}
+
+func isNextTrack(rc api.ReplicationController) bool {
+	return rc.ObjectMeta.Labels[trackLabel] == nextTrack
+}
@@ -0,0 +1,185 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CenturyLinkLabs/pmxadapter"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// Deploys test-service, host port and all, the way CreateServices does, then
+// moves it on to a second revision running two pods.
+func setupUpdate() {
+	servicesSetup()
+	services[0].Deployment.Count = 2
+	if _, err := adapter.CreateServices(services); err != nil {
+		panic(err)
+	}
+	te.RCs[0].Spec.Template.Spec.Containers[0].Image = "redis:2.8"
+	recordRevision(&te.RCs[0])
+	te.CreatedSpec = api.ReplicationController{}
+	te.Pods = revisionPods()
+	te.ReplacePods = true
+	pollInterval = time.Millisecond
+	replacementTimeout = 20 * time.Millisecond
+}
+
+func startUpdate(t *testing.T, strategy string) {
+	_, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: strategy, Image: "redis:3.0"})
+	assert.NoError(t, err)
+	te.UpdatedKServices = nil
+}
+
+func nextTrackPods(ready bool) []api.Pod {
+	phase := api.PodPending
+	if ready {
+		phase = api.PodRunning
+	}
+
+	pods := make([]api.Pod, 2)
+	for i := range pods {
+		pods[i] = api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Name:   nextName("test-service") + "-pod",
+				Labels: map[string]string{"service-name": "test-service", trackLabel: nextTrack},
+			},
+			Status: api.PodStatus{Phase: phase},
+		}
+	}
+	return pods
+}
+
+func TestSuccessfulStartCanaryUpdate(t *testing.T) {
+	setupUpdate()
+	update, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: CanaryStrategy, Image: "redis:3.0"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, CanaryStrategy, update.Strategy)
+	assert.Equal(t, "redis:3.0", update.Image)
+	assert.Equal(t, 1, update.Replicas)
+
+	rc := te.RCs[0]
+	assert.Equal(t, stableTrack, rc.Spec.Selector[trackLabel])
+	assert.Equal(t, stableTrack, rc.Spec.Template.ObjectMeta.Labels[trackLabel])
+	assert.Contains(t, rc.ObjectMeta.Annotations[updateAnnotation], `"strategy":"canary"`)
+	if assert.Len(t, te.UpdatedPods, 2) {
+		assert.Equal(t, stableTrack, te.UpdatedPods[0].ObjectMeta.Labels[trackLabel])
+	}
+
+	next := te.CreatedSpec
+	assert.Equal(t, "test-service-next", next.ObjectMeta.Name)
+	assert.Equal(t, 1, next.Spec.Replicas)
+	assert.Equal(t, map[string]string{"service-name": "test-service", trackLabel: nextTrack}, next.Spec.Selector)
+	assert.Equal(t, "redis:3.0", next.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, nextTrack, next.Spec.Template.ObjectMeta.Labels[trackLabel])
+	assert.Empty(t, next.Spec.Template.ObjectMeta.Labels[revisionLabel])
+	assert.Equal(t, "redis:2.8", rc.Spec.Template.Spec.Containers[0].Image)
+
+	if assert.Len(t, te.UpdatedKServices, 1) {
+		assert.Equal(t, map[string]string{"panamax": "panamax", "service-name": "test-service"}, te.UpdatedKServices[0].Spec.Selector)
+	}
+}
+
+func TestSuccessfulStartBlueGreenUpdate(t *testing.T) {
+	setupUpdate()
+	update, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: BlueGreenStrategy, Image: "redis:3.0", Replicas: 5})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, update.Replicas)
+	assert.Equal(t, 2, te.CreatedSpec.Spec.Replicas)
+	if assert.Len(t, te.UpdatedKServices, 1) {
+		assert.Equal(t, stableTrack, te.UpdatedKServices[0].Spec.Selector[trackLabel])
+	}
+}
+
+func TestErroredStartServiceUpdate(t *testing.T) {
+	for _, opts := range []UpdateOptions{
+		{Strategy: "rolling", Image: "redis:3.0"},
+		{Strategy: CanaryStrategy, Image: "Redis"},
+		{Strategy: CanaryStrategy, Image: "redis:3.0", Replicas: -1},
+		{Strategy: CanaryStrategy, Image: "redis:3.0", Replicas: maxReplicas + 1},
+	} {
+		setupUpdate()
+		_, err := adapter.StartServiceUpdate("test-service", opts)
+
+		if assert.IsType(t, &pmxadapter.Error{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*pmxadapter.Error).Code)
+		}
+		assert.Empty(t, te.UpdatedRCs)
+		assert.Empty(t, te.CreatedSpec.ObjectMeta.Name)
+	}
+}
+
+func TestErroredPolicyStartServiceUpdate(t *testing.T) {
+	setupUpdate()
+	policy.AllowedRegistries = []string{"registry.example.com"}
+	_, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: CanaryStrategy, Image: "redis:3.0"})
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestErroredApplicationPolicyStartServiceUpdate(t *testing.T) {
+	setupUpdate()
+	policy.MaxReplicasPerApplication = 3
+	_, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: BlueGreenStrategy, Image: "redis:3.0"})
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*pmxadapter.Error).Code)
+		assert.Contains(t, err.(*pmxadapter.Error).Message, "application would have 4 replicas")
+	}
+	assert.Empty(t, te.UpdatedRCs)
+}
+
+func TestErroredAlreadyUpdatingStartServiceUpdate(t *testing.T) {
+	setupUpdate()
+	startUpdate(t, CanaryStrategy)
+	_, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: CanaryStrategy, Image: "redis:3.2"})
+
+	if assert.IsType(t, &pmxadapter.Error{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*pmxadapter.Error).Code)
+	}
+}
+
+func TestSuccessfulHostPortStartServiceUpdate(t *testing.T) {
+	setupUpdate()
+	_, err := adapter.StartServiceUpdate("test-service", UpdateOptions{Strategy: CanaryStrategy, Image: "redis:3.0"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, te.CreatedSpec.Spec.Template.Spec.Containers[0].Ports[0].HostPort)
+	assert.Equal(t, 12345, te.CreatedSpec.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+	assert.Equal(t, 31981, te.RCs[0].Spec.Template.Spec.Containers[0].Ports[0].HostPort)
+	if assert.Len(t, te.KServices, 1) {
+		assert.Equal(t, 31981, te.KServices[0].Spec.Port)
+	}
+}
+
+func TestGetServicesHidesNextTrack(t *testing.T) {
+	setupUpdate()
+	next := nextReplicationController(te.RCs[0], ServiceUpdate{Image: "redis:3.0", Replicas: 1})
+	te.RCs = append(te.RCs, next)
+	sds, err := adapter.GetServices()
+
+	assert.NoError(t, err)
+	if assert.Len(t, sds, 1) {
+		assert.Equal(t, "test-service", sds[0].ID)
+	}
+}
+
+func TestGetServicesLeavesOutNextTrackPods(t *testing.T) {
+	setupUpdate()
+	startUpdate(t, CanaryStrategy)
+	te.RCs[0].Status.Replicas = 2
+	te.Pods = append(te.Pods, nextTrackPods(true)...)
+	sds, err := adapter.GetServices()
+
+	assert.NoError(t, err)
+	if assert.Len(t, sds, 1) {
+		assert.Equal(t, "running 2/2", sds[0].ActualState)
+	}
+}
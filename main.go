@@ -1,13 +1,62 @@
 package main // import "github.com/CenturyLinkLabs/panamax-kubernetes-adapter-go"
 
 import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
 	"github.com/CenturyLinkLabs/panamax-kubernetes-adapter-go/adapter"
 	"github.com/CenturyLinkLabs/pmxadapter"
 )
 
 func main() {
-	adapter := adapter.KubernetesAdapter{}
-	server := pmxadapter.NewServer(adapter)
+	if len(os.Args) > 1 && os.Args[1] == "port-forward" {
+		portForward(os.Args[2:])
+		return
+	}
+
+	a := adapter.KubernetesAdapter{}
+	server := pmxadapter.NewServer(a)
 
+	go adapter.StartExtensionServer(a)
+	if adapter.OrphanCollectionInterval > 0 {
+		go a.CollectOrphansEvery(adapter.OrphanCollectionInterval)
+	}
 	server.Start()
 }
+
+// portForward lets a developer reach a service's pod from their own machine,
+// with the same KUBERNETES_* settings as the adapter itself, until
+// interrupted.
+func portForward(args []string) {
+	fs := flag.NewFlagSet("port-forward", flag.ExitOnError)
+	pod := fs.String("pod", "", "forward to this pod of the service rather than any running one")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: panamax-kubernetes-adapter port-forward [-pod NAME] SERVICE [LOCAL:]REMOTE...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// client/portforward reports the ports it listens on and the connections
+	// it loses through glog, which otherwise only writes to files.
+	flag.Set("logtostderr", "true")
+
+	stop := make(chan struct{})
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		close(stop)
+	}()
+
+	a := adapter.KubernetesAdapter{}
+	if err := a.ForwardPorts(fs.Arg(0), *pod, fs.Args()[1:], stop); err != nil {
+		log.Fatal(err)
+	}
+}